@@ -0,0 +1,97 @@
+package extension
+
+import (
+	"path/filepath"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+func callHierarchyItem(tf *taskfile.Taskfile, task *taskfile.Task) protocol.CallHierarchyItem {
+	return protocol.CallHierarchyItem{
+		Name:           task.Name,
+		Kind:           lsp.SKFunction,
+		Detail:         filepath.Base(tf.Path),
+		URI:            GetURI(tf.Path),
+		Range:          ToLSPRange(task.Range),
+		SelectionRange: ToLSPRange(task.NameRange),
+	}
+}
+
+// itemTask returns the task of a call hierarchy item
+func (t *TaskfileExtension) itemTask(item protocol.CallHierarchyItem) (*taskfile.Taskfile, *taskfile.Task, *jsonrpc.ResponseError) {
+	p, err := GetPath(item.URI)
+	if err != nil {
+		return nil, nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return nil, nil, nil
+	}
+	return tf, tf.Tasks[item.Name], nil
+}
+
+// TextDocumentPrepareCallHierarchy returns the task defined or called at a position
+func (t *TaskfileExtension) TextDocumentPrepareCallHierarchy(params *lsp.TextDocumentPositionParams) ([]protocol.CallHierarchyItem, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return nil, nil
+	}
+	sym, ok := tf.SymbolAt(params.Position.Line, params.Position.Character)
+	if !ok || sym.Kind != taskfile.SymbolTask {
+		return nil, nil
+	}
+	target, task := tf.ResolveCall(sym.Name)
+	if task == nil {
+		return nil, nil
+	}
+	return []protocol.CallHierarchyItem{callHierarchyItem(target, task)}, nil
+}
+
+// CallHierarchyIncomingCalls returns the tasks calling a task, from the Taskfiles known by the server and their includes
+func (t *TaskfileExtension) CallHierarchyIncomingCalls(params *protocol.CallHierarchyIncomingCallsParams) ([]protocol.CallHierarchyIncomingCall, *jsonrpc.ResponseError) {
+	calls := make([]protocol.CallHierarchyIncomingCall, 0)
+	tf, task, rerr := t.itemTask(params.Item)
+	if rerr != nil || task == nil {
+		return calls, rerr
+	}
+	for _, d := range tf.Dependents(task.Name) {
+		ranges := make([]lsp.Range, 0, len(d.Calls))
+		for _, c := range d.Calls {
+			ranges = append(ranges, ToLSPRange(c.Range))
+		}
+		calls = append(calls, protocol.CallHierarchyIncomingCall{From: callHierarchyItem(d.Taskfile, d.Task), FromRanges: ranges})
+	}
+	return calls, nil
+}
+
+// CallHierarchyOutgoingCalls returns the tasks called by a task, in the order of their first call
+func (t *TaskfileExtension) CallHierarchyOutgoingCalls(params *protocol.CallHierarchyOutgoingCallsParams) ([]protocol.CallHierarchyOutgoingCall, *jsonrpc.ResponseError) {
+	calls := make([]protocol.CallHierarchyOutgoingCall, 0)
+	tf, task, rerr := t.itemTask(params.Item)
+	if rerr != nil || task == nil {
+		return calls, rerr
+	}
+	indices := make(map[string]int)
+	for _, c := range task.Calls() {
+		target, called := tf.ResolveCall(c.Task)
+		if called == nil {
+			continue
+		}
+		key := target.Path + ":" + called.Name
+		i, ok := indices[key]
+		if !ok {
+			i = len(calls)
+			indices[key] = i
+			calls = append(calls, protocol.CallHierarchyOutgoingCall{To: callHierarchyItem(target, called), FromRanges: make([]lsp.Range, 0)})
+		}
+		calls[i].FromRanges = append(calls[i].FromRanges, ToLSPRange(c.Range))
+	}
+	return calls, nil
+}
@@ -0,0 +1,413 @@
+package extension
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// Fix is a set of edits solving a problem
+type Fix struct {
+	Title     string
+	Kind      lsp.CodeActionKind
+	Preferred bool
+	Edits     []lsp.TextEdit
+}
+
+// TextDocumentCodeAction provides the fixes of the problems found in the requested range
+func (t *TaskfileExtension) TextDocumentCodeAction(params *lsp.CodeActionParams) ([]protocol.CodeAction, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	actions := make([]protocol.CodeAction, 0)
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return actions, nil
+	}
+	for _, problem := range tf.Check() {
+		if !overlaps(problem.Range, params.Range) {
+			continue
+		}
+		diagnostic := DiagnosticFromProblem(problem)
+		for _, fix := range Fixes(tf, problem) {
+			actions = append(actions, protocol.CodeAction{
+				Title:       fix.Title,
+				Kind:        fix.Kind,
+				Diagnostics: []lsp.Diagnostic{diagnostic},
+				IsPreferred: fix.Preferred,
+				Edit:        &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{string(params.TextDocument.URI): fix.Edits}},
+			})
+		}
+	}
+	if action := extractAction(tf, params.TextDocument.URI, params.Range); action != nil {
+		actions = append(actions, *action)
+	}
+	if action := inlineAction(tf, params.TextDocument.URI, params.Range); action != nil {
+		actions = append(actions, *action)
+	}
+	return actions, nil
+}
+
+// overlaps returns true if a Range and a LSP range share at least a position
+func overlaps(r taskfile.Range, other lsp.Range) bool {
+	before := func(line int, col int, pos lsp.Position) bool {
+		return line < pos.Line || (line == pos.Line && col <= pos.Character)
+	}
+	return before(r[0], r[1], other.End) && !before(r[2], r[3]-1, other.Start)
+}
+
+// Fixes returns the fixes available for a problem
+func Fixes(tf *taskfile.Taskfile, problem taskfile.Problem) []Fix {
+	fixes := make([]Fix, 0)
+	switch problem.Code {
+	case taskfile.ProblemMissingVersion:
+		fixes = append(fixes, Fix{
+			Title:     "Add version '3'",
+			Kind:      lsp.CAKQuickFix,
+			Preferred: true,
+			Edits:     []lsp.TextEdit{{Range: ToLSPRange(taskfile.Range{0, 0, 0, 0}), NewText: "version: '3'\n\n"}},
+		})
+	case taskfile.ProblemUnknownTask:
+		if problem.Suggestion != "" {
+			fixes = append(fixes, Fix{
+				Title:     fmt.Sprintf("Replace with %s", problem.Suggestion),
+				Kind:      lsp.CAKQuickFix,
+				Preferred: true,
+				Edits:     []lsp.TextEdit{{Range: ToLSPRange(problem.Range), NewText: problem.Suggestion}},
+			})
+		}
+		// Tasks of the included Taskfiles are created in their Taskfile
+		if tf.IsNamespaced(problem.Name) {
+			break
+		}
+		ti, step := taskIndentation(tf)
+		fixes = append(fixes, Fix{
+			Title: fmt.Sprintf("Create task %s", problem.Name),
+			Kind:  lsp.CAKQuickFix,
+			Edits: []lsp.TextEdit{appendLines(tf.Source, tf.Entries["tasks"][2], []string{
+				"",
+				indent(ti) + problem.Name + ":",
+				indent(ti+step) + "cmds: []",
+			})},
+		})
+	case taskfile.ProblemUndefinedVar:
+		edit := defineVars(tf, tf.Tasks[problem.Task], []string{fmt.Sprintf("%s: \"\"", problem.Name)})
+		if edit == nil {
+			break
+		}
+		title := fmt.Sprintf("Define %s in the vars of the Taskfile", problem.Name)
+		if problem.Task != "" {
+			title = fmt.Sprintf("Define %s in the vars of %s", problem.Name, problem.Task)
+		}
+		fixes = append(fixes, Fix{Title: title, Kind: lsp.CAKQuickFix, Edits: []lsp.TextEdit{*edit}})
+	case taskfile.ProblemStringCmds:
+		edit := cmdsToList(tf, tf.Tasks[problem.Task])
+		if edit != nil {
+			fixes = append(fixes, Fix{Title: "Convert cmds to a list", Kind: lsp.CAKQuickFix, Preferred: true, Edits: []lsp.TextEdit{*edit}})
+		}
+	case taskfile.ProblemDuplicateCmds:
+		name := uniqueTaskName(tf, "common")
+		edits := extractCmds(tf, problem.Occurrences, name)
+		if edits != nil {
+			fixes = append(fixes, Fix{
+				Title: fmt.Sprintf("Extract the repeated commands into task %s", name),
+				Kind:  lsp.CAKRefactorExtract,
+				Edits: edits,
+			})
+		}
+	}
+	return fixes
+}
+
+func indent(n int) string {
+	return strings.Repeat(" ", n)
+}
+
+// taskIndentation returns the indentation of the tasks and the indentation step of the Taskfile
+func taskIndentation(tf *taskfile.Taskfile) (int, int) {
+	ti := -1
+	for _, task := range tf.Tasks {
+		if ti < 0 || task.NameRange[1] < ti {
+			ti = task.NameRange[1]
+		}
+	}
+	if ti <= 0 {
+		return 2, 2
+	}
+	return ti, ti
+}
+
+// taskStep returns the indentation step used by the properties of a task
+func taskStep(tf *taskfile.Taskfile, task *taskfile.Task) int {
+	for _, k := range task.Keys {
+		if k[0] > task.NameRange[0] && k[1] > task.NameRange[1] {
+			return k[1] - task.NameRange[1]
+		}
+	}
+	_, step := taskIndentation(tf)
+	return step
+}
+
+// appendLines inserts lines after a line of the source
+func appendLines(src *taskfile.Source, line int, lines []string) lsp.TextEdit {
+	text := strings.Join(lines, "\n")
+	if line+1 < src.LineCount() {
+		pos := lsp.Position{Line: line + 1, Character: 0}
+		return lsp.TextEdit{Range: lsp.Range{Start: pos, End: pos}, NewText: text + "\n"}
+	}
+	pos := lsp.Position{Line: line, Character: src.Column(line, len(src.Line(line)))}
+	return lsp.TextEdit{Range: lsp.Range{Start: pos, End: pos}, NewText: "\n" + text}
+}
+
+// textOf returns the text of the source covered by a Range
+func textOf(src *taskfile.Source, r taskfile.Range) string {
+	return src.Text[src.Offset(r[0], r[1]):src.Offset(r[2], r[3])]
+}
+
+// firstVar returns the variable declared first in a document
+func firstVar(vars map[string]*taskfile.Var) *taskfile.Var {
+	var first *taskfile.Var
+	for _, v := range vars {
+		if first == nil || v.NameRange[0] < first.NameRange[0] {
+			first = v
+		}
+	}
+	return first
+}
+
+// defineVars adds declarations to the vars of a task, or of the Taskfile if the task is nil
+// Returns nil if the vars are not written as a block
+func defineVars(tf *taskfile.Taskfile, task *taskfile.Task, declarations []string) *lsp.TextEdit {
+	vars, key := tf.Vars, tf.Entries["vars"]
+	if task != nil {
+		vars, key = task.Vars, task.Keys["vars"]
+	}
+	if first := firstVar(vars); first != nil {
+		if first.NameRange[0] == key[0] {
+			return nil
+		}
+		edit := appendLines(tf.Source, first.NameRange[0]-1, indentLines(declarations, first.NameRange[1]))
+		return &edit
+	}
+	if task == nil {
+		tasks, ok := tf.Entries["tasks"]
+		if !ok {
+			return nil
+		}
+		_, step := taskIndentation(tf)
+		lines := append([]string{"vars:"}, indentLines(declarations, step)...)
+		edit := appendLines(tf.Source, tasks[0]-1, append(lines, ""))
+		return &edit
+	}
+	pi := -1
+	for _, k := range task.Keys {
+		if k[0] == task.NameRange[0] {
+			return nil
+		}
+		pi = k[1]
+	}
+	if pi < 0 {
+		return nil
+	}
+	step := pi - task.NameRange[1]
+	lines := append([]string{indent(pi) + "vars:"}, indentLines(declarations, pi+step)...)
+	edit := appendLines(tf.Source, task.NameRange[0], lines)
+	return &edit
+}
+
+func indentLines(lines []string, n int) []string {
+	indented := make([]string, len(lines))
+	for i, l := range lines {
+		indented[i] = indent(n) + l
+	}
+	return indented
+}
+
+// shiftLines indents the lines following the first line of a text
+func shiftLines(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = indent(n) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cmdsToList turns the string of the cmds of a task into the only item of a list
+func cmdsToList(tf *taskfile.Taskfile, task *taskfile.Task) *lsp.TextEdit {
+	key, ok := task.Keys["cmds"]
+	if !ok || len(task.Commands) != 1 {
+		return nil
+	}
+	value := task.Commands[0].Range
+	item := "- " + shiftLines(textOf(tf.Source, value), 2)
+	if value[0] != key[0] {
+		return &lsp.TextEdit{Range: ToLSPRange(value), NewText: item}
+	}
+	r := taskfile.Range{key[2], key[3], value[2], value[3]}
+	return &lsp.TextEdit{Range: ToLSPRange(r), NewText: ":\n" + indent(key[1]+taskStep(tf, task)) + item}
+}
+
+// uniqueTaskName returns a name that is not used by a task
+func uniqueTaskName(tf *taskfile.Taskfile, base string) string {
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := tf.Tasks[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// referencedVars returns the variables of a task used by the expressions of some lines
+// Variables are declared in the task or passed by its callers, they are not visible from the tasks it calls
+func referencedVars(tf *taskfile.Taskfile, task *taskfile.Task, startLine int, endLine int) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, e := range task.Expressions {
+		if e.Range[0] < startLine || e.Range[2] > endLine {
+			continue
+		}
+		for _, tk := range e.Tokens {
+			name := tk.FieldName()
+			if name == "" || seen[name] || !isTaskVar(tf, task, name) {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isTaskVar(tf *taskfile.Taskfile, task *taskfile.Task, name string) bool {
+	if _, ok := task.Vars[name]; ok {
+		return true
+	}
+	return tf.IsPassedVar(task, name)
+}
+
+// extractCmds moves commands into a new task and replaces them by a call to this task
+// The variables of the task used by the commands are passed to the new task
+// Returns nil if the commands are not items of a block sequence starting their lines
+func extractCmds(tf *taskfile.Taskfile, occurrences []taskfile.Occurrence, name string) []lsp.TextEdit {
+	src := tf.Source
+	sort.Slice(occurrences, func(i, j int) bool {
+		return tf.Tasks[occurrences[i].Task].Commands[occurrences[i].Start].Range[0] < tf.Tasks[occurrences[j].Task].Commands[occurrences[j].Start].Range[0]
+	})
+	ti, step := taskIndentation(tf)
+	edits := make([]lsp.TextEdit, 0)
+	var extracted []string
+	itemIndent := 0
+	for _, o := range occurrences {
+		task := tf.Tasks[o.Task]
+		if task.CmdsFlow || task.CmdsString {
+			return nil
+		}
+		start, end := task.Commands[o.Start].Range, task.Commands[o.End-1].Range
+		line := src.Line(start[0])
+		if strings.TrimSpace(line[:taskfile.DecodeColumn(line, start[1], src.Encoding)]) != "-" {
+			return nil
+		}
+		if extracted == nil {
+			itemIndent = taskfile.Indentation(line)
+			extracted = make([]string, 0)
+			for l := start[0]; l <= end[2]; l++ {
+				extracted = append(extracted, src.Line(l))
+			}
+		}
+		ind := taskfile.Indentation(line)
+		call := []string{indent(ind) + "- task: " + name}
+		if vars := referencedVars(tf, task, start[0], end[2]); len(vars) > 0 {
+			call = append(call, indent(ind+2)+"vars:")
+			for _, v := range vars {
+				call = append(call, fmt.Sprintf("%s%s: '{{.%s}}'", indent(ind+2+step), v, v))
+			}
+		}
+		edits = append(edits, replaceLines(src, start[0], end[2], call))
+	}
+	lines := []string{"", indent(ti) + name + ":", indent(ti+step) + "cmds:"}
+	lines = append(lines, shiftBlock(extracted, ti+2*step-itemIndent)...)
+	return append(edits, appendLines(src, tf.Entries["tasks"][2], lines))
+}
+
+// selectedCmds returns the commands of a task covered by a selection, End is excluded
+func selectedCmds(tf *taskfile.Taskfile, r lsp.Range) (taskfile.Occurrence, bool) {
+	endLine := r.End.Line
+	// A selection of whole lines ends at the start of the next line
+	if r.End.Character == 0 && endLine > r.Start.Line {
+		endLine--
+	}
+	for _, task := range tf.Tasks {
+		if task.CmdsFlow || task.CmdsString {
+			continue
+		}
+		o := taskfile.Occurrence{Task: task.Name, Start: -1}
+		for i, c := range task.Commands {
+			if c.Range[2] < r.Start.Line || c.Range[0] > endLine {
+				continue
+			}
+			if o.Start < 0 {
+				o.Start = i
+			}
+			o.End = i + 1
+		}
+		if o.Start >= 0 {
+			return o, true
+		}
+	}
+	return taskfile.Occurrence{}, false
+}
+
+// extractAction moves the selected commands into a new task, the name of the task is asked by the client
+// or the task is renamed once extracted
+func extractAction(tf *taskfile.Taskfile, uri lsp.DocumentURI, r lsp.Range) *protocol.CodeAction {
+	if r.Start == r.End {
+		return nil
+	}
+	o, ok := selectedCmds(tf, r)
+	if !ok {
+		return nil
+	}
+	return &protocol.CodeAction{
+		Title: "Extract the commands into a new task",
+		Kind:  lsp.CAKRefactorExtract,
+		Command: &lsp.Command{
+			Title:     "Extract the commands into a new task",
+			Command:   CommandExtractTask,
+			Arguments: []interface{}{uri, o.Task, o.Start, o.End},
+		},
+	}
+}
+
+// shiftBlock indents or dedents lines, empty lines stay empty
+func shiftBlock(lines []string, shift int) []string {
+	shifted := make([]string, len(lines))
+	for i, l := range lines {
+		switch {
+		case strings.TrimSpace(l) == "":
+			shifted[i] = ""
+		case shift < 0:
+			shifted[i] = l[minIndent(l, -shift):]
+		default:
+			shifted[i] = indent(shift) + l
+		}
+	}
+	return shifted
+}
+
+// minIndent returns n or the indentation of the line if it is smaller
+func minIndent(line string, n int) int {
+	if i := taskfile.Indentation(line); i < n {
+		return i
+	}
+	return n
+}
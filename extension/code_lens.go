@@ -0,0 +1,49 @@
+package extension
+
+import (
+	"fmt"
+	"sort"
+	"taskfile-language-server/jsonrpc"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+func dependentsTitle(count int) string {
+	if count == 1 {
+		return "1 dependent"
+	}
+	return fmt.Sprintf("%d dependents", count)
+}
+
+// TextDocumentCodeLens shows the commands of each task above its name
+func (t *TaskfileExtension) TextDocumentCodeLens(params *lsp.CodeLensParams) ([]lsp.CodeLens, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	lenses := make([]lsp.CodeLens, 0)
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return lenses, nil
+	}
+	tasks := make([]*taskfile.Task, 0, len(tf.Tasks))
+	for _, task := range tf.Tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Range[0] < tasks[j].Range[0] })
+	for _, task := range tasks {
+		r := ToLSPRange(task.NameRange)
+		args := []interface{}{params.TextDocument.URI, task.Name}
+		lenses = append(lenses,
+			lsp.CodeLens{Range: r, Command: lsp.Command{Title: "Run", Command: CommandRunTask, Arguments: args}},
+			lsp.CodeLens{Range: r, Command: lsp.Command{Title: "Dry run", Command: CommandDryRunTask, Arguments: args}},
+			lsp.CodeLens{Range: r, Command: lsp.Command{
+				Title:     dependentsTitle(len(tf.Dependents(task.Name))),
+				Command:   CommandShowDependents,
+				Arguments: args,
+			}},
+		)
+	}
+	return lenses, nil
+}
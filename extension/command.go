@@ -0,0 +1,179 @@
+package extension
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// Commands handled by workspace/executeCommand
+// They all take the URI of the Taskfile and the name of a task as first arguments
+const (
+	CommandRunTask        = "taskfile.runTask"
+	CommandDryRunTask     = "taskfile.dryRunTask"
+	CommandShowDependents = "taskfile.showDependents"
+	// Takes the indices of the first and after the last commands to extract, then optionally the name of the new task
+	CommandExtractTask = "taskfile.extractTask"
+	// Returns the shell commands of the task with their templates rendered
+	CommandRenderCommands = "taskfile.renderCommands"
+)
+
+var Commands = []string{CommandRunTask, CommandDryRunTask, CommandShowDependents, CommandExtractTask, CommandRenderCommands}
+
+// taskArguments extracts the URI of the Taskfile and the name of the task from the arguments of a command
+func taskArguments(args []interface{}) (lsp.DocumentURI, string, *jsonrpc.ResponseError) {
+	if len(args) < 2 {
+		return "", "", jsonrpc.NewError(jsonrpc.InvalidParams, fmt.Sprintf("Expected at least 2 arguments, received %d", len(args)), nil)
+	}
+	uri, ok := args[0].(string)
+	if !ok {
+		return "", "", jsonrpc.NewError(jsonrpc.InvalidParams, "The first argument must be the URI of a Taskfile", nil)
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return "", "", jsonrpc.NewError(jsonrpc.InvalidParams, "The second argument must be the name of a task", nil)
+	}
+	return lsp.DocumentURI(uri), name, nil
+}
+
+func (t *TaskfileExtension) WorkspaceExecuteCommand(params *lsp.ExecuteCommandParams) (interface{}, *jsonrpc.ResponseError) {
+	uri, name, resErr := taskArguments(params.Arguments)
+	if resErr != nil {
+		return nil, resErr
+	}
+	p, err := GetPath(uri)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return nil, jsonrpc.NewError(protocol.RequestFailed, "Could not find taskfile", nil)
+	}
+	if _, ok := tf.Tasks[name]; !ok {
+		return nil, jsonrpc.NewError(protocol.RequestFailed, fmt.Sprintf("Task %s does not exist", name), nil)
+	}
+	switch params.Command {
+	case CommandRunTask:
+		return nil, t.runTask(p, name, false)
+	case CommandDryRunTask:
+		return nil, t.runTask(p, name, true)
+	case CommandShowDependents:
+		return t.showDependents(tf, name), nil
+	case CommandExtractTask:
+		return nil, t.extractTask(uri, tf, name, params.Arguments[2:])
+	case CommandRenderCommands:
+		return tf.RenderCommands(tf.Tasks[name]), nil
+	}
+	return nil, jsonrpc.NewError(jsonrpc.InvalidParams, fmt.Sprintf("Unknown command %s", params.Command), nil)
+}
+
+// runTask starts a task in the directory of its Taskfile
+// The output is sent to the client as log messages, the result is shown once the task ends
+func (t *TaskfileExtension) runTask(path string, name string, dry bool) *jsonrpc.ResponseError {
+	args := []string{"--taskfile", path}
+	if dry {
+		args = append(args, "--dry")
+	}
+	args = append(args, name)
+	cmd := exec.Command(t.settings.Run.Executable, args...)
+	cmd.Dir = filepath.Dir(path)
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	err := cmd.Start()
+	if err != nil {
+		return jsonrpc.NewError(protocol.RequestFailed, err.Error(), nil)
+	}
+	t.LogMessage(lsp.Info, fmt.Sprintf("> %s %s", t.settings.Run.Executable, strings.Join(args, " ")))
+	go func() {
+		done := make(chan bool)
+		go func() {
+			scanner := bufio.NewScanner(reader)
+			for scanner.Scan() {
+				t.LogMessage(lsp.Log, scanner.Text())
+			}
+			close(done)
+		}()
+		err := cmd.Wait()
+		writer.Close()
+		<-done
+		if err != nil {
+			t.ShowMessage(lsp.MTError, fmt.Sprintf("Task %s failed: %s", name, err))
+			return
+		}
+		t.ShowMessage(lsp.Info, fmt.Sprintf("Task %s succeeded", name))
+	}()
+	return nil
+}
+
+// showDependents returns the locations calling a task and lists the dependents to the user
+func (t *TaskfileExtension) showDependents(tf *taskfile.Taskfile, name string) []lsp.Location {
+	locations := make([]lsp.Location, 0)
+	names := make([]string, 0)
+	for _, d := range tf.Dependents(name) {
+		if d.Taskfile.Path == tf.Path {
+			names = append(names, d.Task.Name)
+		} else {
+			rel, err := filepath.Rel(filepath.Dir(tf.Path), d.Taskfile.Path)
+			if err != nil {
+				rel = d.Taskfile.Path
+			}
+			names = append(names, fmt.Sprintf("%s (%s)", d.Task.Name, filepath.ToSlash(rel)))
+		}
+		for _, c := range d.Calls {
+			locations = append(locations, lsp.Location{URI: GetURI(d.Taskfile.Path), Range: ToLSPRange(c.Range)})
+		}
+	}
+	if len(names) == 0 {
+		t.ShowMessage(lsp.Info, fmt.Sprintf("No task depends on %s", name))
+	} else {
+		t.ShowMessage(lsp.Info, fmt.Sprintf("%s: %s", dependentsTitle(len(names)), strings.Join(names, ", ")))
+	}
+	return locations
+}
+
+// extractTask moves commands of a task into a new task, named by the last argument
+// Without a name given by the client, the new task takes a name derived from the task for the user to rename it
+func (t *TaskfileExtension) extractTask(uri lsp.DocumentURI, tf *taskfile.Taskfile, name string, args []interface{}) *jsonrpc.ResponseError {
+	if len(args) < 2 {
+		return jsonrpc.NewError(jsonrpc.InvalidParams, "Expected the indices of the commands to extract", nil)
+	}
+	start, ok := args[0].(float64)
+	end, ok2 := args[1].(float64)
+	cmds := tf.Tasks[name].Commands
+	if !ok || !ok2 || start < 0 || end <= start || int(end) > len(cmds) {
+		return jsonrpc.NewError(jsonrpc.InvalidParams, "Invalid indices of the commands to extract", nil)
+	}
+	newName := uniqueTaskName(tf, name+"-extracted")
+	named := len(args) > 2
+	if named {
+		newName, ok = args[2].(string)
+		if !ok || strings.TrimSpace(newName) == "" {
+			return jsonrpc.NewError(jsonrpc.InvalidParams, "The name of the new task must be a string", nil)
+		}
+		if _, exists := tf.Tasks[newName]; exists {
+			return jsonrpc.NewError(protocol.RequestFailed, fmt.Sprintf("Task %s already exists", newName), nil)
+		}
+	}
+	edits := extractCmds(tf, []taskfile.Occurrence{{Task: name, Start: int(start), End: int(end)}}, newName)
+	if edits == nil {
+		return jsonrpc.NewError(protocol.RequestFailed, "The commands must be items of a block sequence", nil)
+	}
+	edit := lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{string(uri): edits}}
+	err := t.ApplyEdit(fmt.Sprintf("Extract task %s", newName), edit)
+	if err != nil {
+		return jsonrpc.NewError(protocol.RequestFailed, err.Error(), nil)
+	}
+	if !named {
+		t.ShowMessage(lsp.Info, fmt.Sprintf("The commands were extracted into task %s, rename it to name it", newName))
+	}
+	return nil
+}
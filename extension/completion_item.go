@@ -1,9 +1,119 @@
 package extension
 
-import "github.com/sourcegraph/go-lsp"
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+)
 
-import "taskfile-language-server/jsonrpc"
+// GetCompletionItemData extracts the data payload sent back by the client
+func GetCompletionItemData(item *protocol.CompletionItem) (*CompletionItemData, error) {
+	raw, err := json.Marshal(item.Data)
+	if err != nil {
+		return nil, err
+	}
+	data := &CompletionItemData{}
+	err = json.Unmarshal(raw, data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// VarDocumentation renders a variable definition as a yaml snippet
+func VarDocumentation(v *taskfile.Var) string {
+	if v.Sh != "" {
+		return fmt.Sprintf("```yaml\n%s:\n  sh: %s\n```", v.Name, v.Sh)
+	}
+	return fmt.Sprintf("```yaml\n%s: %s\n```", v.Name, v.Value)
+}
 
-func (t *TaskfileExtension) CompletionItemResolve(item *lsp.CompletionItem) (*lsp.CompletionItem, *jsonrpc.ResponseError) {
+func (t *TaskfileExtension) CompletionItemResolve(item *protocol.CompletionItem) (*protocol.CompletionItem, *jsonrpc.ResponseError) {
+	data, err := GetCompletionItemData(item)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InvalidParams, err.Error(), nil)
+	}
+	if data.Kind == KindFunction {
+		item.Detail = "Function of Task"
+		if taskfile.BuiltinFuncs[data.Name] {
+			item.Detail = "Function of text/template"
+		}
+		return item, nil
+	}
+	if data.Scope == ScopeGlobal {
+		item.Detail = "Special variable"
+		return item, nil
+	}
+	tf := t.taskfiles.Get(data.Path)
+	if tf == nil {
+		// The Taskfile could not be parsed, nothing to add to the item
+		return item, nil
+	}
+	switch data.Kind {
+	case KindVar:
+		if data.Task == "" {
+			return item, nil
+		}
+		task, ok := tf.Tasks[data.Task]
+		if !ok {
+			return item, nil
+		}
+		// The values are resolved for the item alone, the list only has the names
+		r, ok := tf.TaskVars(task)[data.Name]
+		if !ok || r.Decl == nil {
+			return item, nil
+		}
+		item.Detail = "Taskfile variable"
+		if data.Scope == ScopeTask {
+			item.Detail = fmt.Sprintf("Variable of task %s", data.Task)
+		}
+		doc := VarDocumentation(r.Decl) + "\n\n" + valueMarkdown(r.Value)
+		item.Documentation = &protocol.MarkupContent{Kind: protocol.Markdown, Value: doc}
+	case KindEnv:
+		var task *taskfile.Task
+		if data.Task != "" {
+			task = tf.Tasks[data.Task]
+			if task == nil {
+				return item, nil
+			}
+		}
+		env := tf.Env
+		switch data.Scope {
+		case ScopeTask:
+			env = task.Env
+		case ScopeDotenv:
+			env = tf.DotenvVars(task)
+		}
+		v, ok := env[data.Name]
+		if !ok {
+			return item, nil
+		}
+		item.Detail = "Taskfile environment variable"
+		doc := VarDocumentation(v)
+		switch data.Scope {
+		case ScopeTask:
+			item.Detail = fmt.Sprintf("Environment variable of task %s", data.Task)
+		case ScopeDotenv:
+			item.Detail = fmt.Sprintf("Variable of %s", filepath.Base(v.File))
+			doc = fmt.Sprintf("```sh\n%s=%s\n```", v.Name, v.Value)
+		}
+		item.Documentation = &protocol.MarkupContent{Kind: protocol.Markdown, Value: doc}
+	case KindTask:
+		_, task := tf.ResolveTask(data.Name)
+		if task == nil {
+			return item, nil
+		}
+		item.Detail = task.Desc.String()
+		doc := task.Summary.String()
+		if doc == "" {
+			doc = task.Desc.String()
+		}
+		if doc != "" {
+			item.Documentation = &protocol.MarkupContent{Kind: protocol.Markdown, Value: doc}
+		}
+	}
 	return item, nil
 }
@@ -0,0 +1,130 @@
+package extension
+
+import (
+	"taskfile-language-server/taskfile"
+	"time"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// DiagnosticSource is the source of the diagnostics published by the server
+const DiagnosticSource = "taskfile"
+
+// DiagnosticsDelay is the time without changes before the problems of a document are checked
+const DiagnosticsDelay = 200 * time.Millisecond
+
+// Severities of the problems, undefined variables might be passed on the command line
+var problemSeverities = map[taskfile.ProblemCode]lsp.DiagnosticSeverity{
+	taskfile.ProblemMissingVersion:  lsp.Error,
+	taskfile.ProblemUnknownTask:     lsp.Error,
+	taskfile.ProblemUndefinedVar:    lsp.Information,
+	taskfile.ProblemStringCmds:      lsp.Error,
+	taskfile.ProblemDuplicateCmds:   lsp.Hint,
+	taskfile.ProblemMissingInclude:  lsp.Error,
+	taskfile.ProblemIncludeCycle:    lsp.Error,
+	taskfile.ProblemUnknownFunction: lsp.Warning,
+	taskfile.ProblemSyntax:          lsp.Error,
+	taskfile.ProblemMissingDotenv:   lsp.Information,
+}
+
+func DiagnosticFromProblem(p taskfile.Problem) lsp.Diagnostic {
+	return lsp.Diagnostic{
+		Range:    ToLSPRange(p.Range),
+		Severity: problemSeverities[p.Code],
+		Code:     string(p.Code),
+		Source:   DiagnosticSource,
+		Message:  p.Message,
+	}
+}
+
+// scheduleDiagnostics publishes the problems of a document and of its related Taskfiles off the read loop
+// A change arriving before the delay postpones them, the problems of a stale version are not sent
+func (t *TaskfileExtension) scheduleDiagnostics(uri lsp.DocumentURI) {
+	p, err := GetPath(uri)
+	if err != nil {
+		t.Logger.Println(err.Error())
+		return
+	}
+	version, open := t.taskfiles.Version(p)
+	stale := func() bool {
+		v, ok := t.taskfiles.Version(p)
+		return v != version || ok != open
+	}
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+	if pending, ok := t.pending[uri]; ok {
+		pending.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(DiagnosticsDelay, func() {
+		t.pendingMutex.Lock()
+		current := t.pending[uri] == timer
+		if current {
+			delete(t.pending, uri)
+		}
+		t.pendingMutex.Unlock()
+		if !current {
+			return
+		}
+		t.publishing.Lock()
+		defer t.publishing.Unlock()
+		if stale() {
+			return
+		}
+		// A closed document only updates the Taskfiles related to it
+		if open {
+			diagnostics, ok := t.diagnostics(p)
+			if stale() {
+				return
+			}
+			if ok {
+				t.SendNotification("textDocument/publishDiagnostics", &lsp.PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+			}
+		}
+		t.publishRelatedDiagnostics(uri)
+	})
+	t.pending[uri] = timer
+}
+
+// cancelDiagnostics drops the problems of a document waiting to be published
+func (t *TaskfileExtension) cancelDiagnostics(uri lsp.DocumentURI) {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+	if pending, ok := t.pending[uri]; ok {
+		pending.Stop()
+		delete(t.pending, uri)
+	}
+}
+
+// diagnostics returns the problems of a Taskfile, false if it can not be parsed
+func (t *TaskfileExtension) diagnostics(p string) ([]lsp.Diagnostic, bool) {
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return nil, false
+	}
+	diagnostics := make([]lsp.Diagnostic, 0)
+	for _, problem := range tf.Check() {
+		diagnostics = append(diagnostics, DiagnosticFromProblem(problem))
+	}
+	return diagnostics, true
+}
+
+// publishDiagnostics sends the problems of a Taskfile to the client
+// Nothing is sent if the Taskfile can not be parsed, the previous diagnostics are kept
+func (t *TaskfileExtension) publishDiagnostics(uri lsp.DocumentURI) {
+	p, err := GetPath(uri)
+	if err != nil {
+		t.Logger.Println(err.Error())
+		return
+	}
+	if diagnostics, ok := t.diagnostics(p); ok {
+		t.SendNotification("textDocument/publishDiagnostics", &lsp.PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+	}
+}
+
+// clearDiagnostics removes the diagnostics of a document, after the ones being published
+func (t *TaskfileExtension) clearDiagnostics(uri lsp.DocumentURI) {
+	t.publishing.Lock()
+	defer t.publishing.Unlock()
+	t.SendNotification("textDocument/publishDiagnostics", &lsp.PublishDiagnosticsParams{URI: uri, Diagnostics: make([]lsp.Diagnostic, 0)})
+}
@@ -0,0 +1,32 @@
+package extension
+
+import (
+	"taskfile-language-server/jsonrpc"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// TextDocumentDocumentHighlight highlights the declarations and the usages of the task or the variable at a position
+func (t *TaskfileExtension) TextDocumentDocumentHighlight(params *lsp.TextDocumentPositionParams) ([]lsp.DocumentHighlight, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	highlights := make([]lsp.DocumentHighlight, 0)
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return highlights, nil
+	}
+	sym, ok := tf.SymbolAt(params.Position.Line, params.Position.Character)
+	if !ok {
+		return highlights, nil
+	}
+	declarations, usages := tf.Occurrences(sym)
+	for _, r := range declarations {
+		highlights = append(highlights, lsp.DocumentHighlight{Range: ToLSPRange(r), Kind: lsp.Write})
+	}
+	for _, r := range usages {
+		highlights = append(highlights, lsp.DocumentHighlight{Range: ToLSPRange(r), Kind: lsp.Read})
+	}
+	return highlights, nil
+}
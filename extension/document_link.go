@@ -0,0 +1,117 @@
+package extension
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+)
+
+// MaxGlobEntries is the number of files visited when looking for the first match of a glob
+const MaxGlobEntries = 10000
+
+var errFound = errors.New("found")
+
+// matchSegments matches the segments of a path against the segments of a glob, `**` matches any number of segments
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		return matchSegments(pattern[1:], segments) || (len(segments) > 0 && matchSegments(pattern, segments[1:]))
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, err := filepath.Match(pattern[0], segments[0])
+	return err == nil && ok && matchSegments(pattern[1:], segments[1:])
+}
+
+// FirstMatch returns the first file matching a glob relative to a directory
+func FirstMatch(dir string, glob string) (string, bool) {
+	if !strings.ContainsAny(glob, "*?[") {
+		p := taskfile.ResolvePath(dir, glob)
+		_, err := os.Stat(p)
+		return p, err == nil
+	}
+	// Walk from the part of the glob without wildcards
+	pattern := strings.Split(filepath.ToSlash(glob), "/")
+	root := dir
+	for len(pattern) > 1 && !strings.ContainsAny(pattern[0], "*?[") {
+		root = taskfile.ResolvePath(root, pattern[0])
+		pattern = pattern[1:]
+	}
+	match := ""
+	visited := 0
+	_ = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		visited++
+		if err != nil || visited > MaxGlobEntries {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err == nil && matchSegments(pattern, strings.Split(filepath.ToSlash(rel), "/")) {
+			match = p
+			return errFound
+		}
+		return nil
+	})
+	return match, match != ""
+}
+
+// linkTarget returns the file a path of a Taskfile points to
+func linkTarget(dir string, p taskfile.Path) (string, bool) {
+	switch p.Kind {
+	case taskfile.PathInclude:
+		return taskfile.FindTaskfile(taskfile.ResolvePath(dir, p.Value))
+	case taskfile.PathSource, taskfile.PathGenerate:
+		if p.Dir != "" {
+			dir = taskfile.ResolvePath(dir, p.Dir)
+		}
+		return FirstMatch(dir, p.Value)
+	case taskfile.PathDotenv:
+		if p.Dir != "" {
+			dir = taskfile.ResolvePath(dir, p.Dir)
+		}
+	}
+	target := taskfile.ResolvePath(dir, p.Value)
+	_, err := os.Stat(target)
+	return target, err == nil
+}
+
+// TextDocumentDocumentLink links the paths of a Taskfile to the files they point to
+func (t *TaskfileExtension) TextDocumentDocumentLink(params *protocol.DocumentLinkParams) ([]protocol.DocumentLink, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	links := make([]protocol.DocumentLink, 0)
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return links, nil
+	}
+	// The Taskfile is shared by the requests, its paths are copied
+	paths := append([]taskfile.Path{}, tf.Paths...)
+	for _, task := range tf.Tasks {
+		paths = append(paths, task.Paths...)
+	}
+	dir := filepath.Dir(p)
+	for _, path := range paths {
+		target, ok := linkTarget(dir, path)
+		if !ok {
+			continue
+		}
+		link := protocol.DocumentLink{Range: ToLSPRange(path.Range), Target: GetURI(target)}
+		if path.Kind == taskfile.PathSource || path.Kind == taskfile.PathGenerate {
+			link.Tooltip = fmt.Sprintf("First match: %s", target)
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
@@ -1,12 +1,19 @@
 package extension
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/url"
+	"path/filepath"
 	"runtime"
+	"sync"
 	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
 	"taskfile-language-server/taskfile"
+	"time"
 
 	"github.com/sourcegraph/go-lsp"
 )
@@ -25,31 +32,77 @@ func GetPath(uri lsp.DocumentURI) (string, error) {
 	return path, nil
 }
 
-func reloadTaskfile(docUri lsp.DocumentURI, text string) error {
-	path, err := GetPath(docUri)
-	if err != nil {
-		return err
+// GetURI converts a path to a file URI, the reverse of GetPath
+func GetURI(path string) lsp.DocumentURI {
+	path = filepath.ToSlash(path)
+	// Add the leading slash if on windows
+	if runtime.GOOS == "windows" {
+		path = "/" + path
+	}
+	u := url.URL{Scheme: "file", Path: path}
+	return lsp.DocumentURI(u.String())
+}
+
+// ToLSPRange converts a Range of a Taskfile to a LSP range
+func ToLSPRange(r taskfile.Range) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: r[0], Character: r[1]},
+		End:   lsp.Position{Line: r[2], Character: r[3]},
 	}
-	taskfile.Invalidate(path, text)
-	return nil
 }
 
 type TaskfileExtension struct {
 	Logger        *log.Logger
 	notifications chan *jsonrpc.Notification
+	// taskfiles holds the buffers of the documents opened in the editor and the Taskfiles parsed from them or from disk
+	taskfiles *taskfile.Store
+	settings  Settings
+	// server sends the requests to the client
+	server *jsonrpc.Server
+	// pending are the diagnostics waiting for the changes of a document to settle
+	pending      map[lsp.DocumentURI]*time.Timer
+	pendingMutex sync.Mutex
+	// publishing orders the diagnostics checked off the read loop
+	publishing sync.Mutex
 }
 
-func New() *TaskfileExtension {
+func New(taskfiles *taskfile.Store) *TaskfileExtension {
 	return &TaskfileExtension{
 		Logger:        log.New(ioutil.Discard, "[taskfile]", log.Ldate|log.Ltime),
 		notifications: make(chan *jsonrpc.Notification),
+		taskfiles:     taskfiles,
+		settings:      GetSettings(nil),
+		pending:       make(map[lsp.DocumentURI]*time.Timer),
 	}
 }
 
 func (t *TaskfileExtension) RegisterHandlers(s *jsonrpc.Server) {
+	t.server = s
 	s.AddHandler("extension/getTasks", t.GetTasks)
 }
 
+// ApplyEditTimeout is how long the client is given to apply a workspace edit
+const ApplyEditTimeout = time.Minute
+
+// ApplyEdit asks the client to apply a workspace edit
+func (t *TaskfileExtension) ApplyEdit(label string, edit lsp.WorkspaceEdit) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ApplyEditTimeout)
+	defer cancel()
+	raw, resErr := t.server.SendRequest(ctx, "workspace/applyEdit", &protocol.ApplyWorkspaceEditParams{Label: label, Edit: edit})
+	if resErr != nil {
+		return fmt.Errorf("%s", resErr.Message)
+	}
+	res := &protocol.ApplyWorkspaceEditResult{}
+	err := json.Unmarshal(raw, res)
+	if err != nil {
+		return err
+	}
+	if !res.Applied {
+		return fmt.Errorf("The edit was not applied: %s", res.FailureReason)
+	}
+	return nil
+}
+
 func (t *TaskfileExtension) SendNotification(method string, contents interface{}) {
 	t.notifications <- &jsonrpc.Notification{
 		Method: method,
@@ -57,6 +110,16 @@ func (t *TaskfileExtension) SendNotification(method string, contents interface{}
 	}
 }
 
+// ShowMessage asks the client to display a message to the user
+func (t *TaskfileExtension) ShowMessage(kind lsp.MessageType, message string) {
+	t.SendNotification("window/showMessage", &lsp.ShowMessageParams{Type: kind, Message: message})
+}
+
+// LogMessage asks the client to log a message
+func (t *TaskfileExtension) LogMessage(kind lsp.MessageType, message string) {
+	t.SendNotification("window/logMessage", &lsp.LogMessageParams{Type: kind, Message: message})
+}
+
 func (t *TaskfileExtension) Notifications() chan *jsonrpc.Notification {
 	return t.notifications
 }
@@ -0,0 +1,53 @@
+package extension
+
+import (
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+)
+
+// foldingRanges collects the folding ranges, ignoring duplicates and single lines
+type foldingRanges struct {
+	ranges []protocol.FoldingRange
+	seen   map[[2]int]bool
+}
+
+func (f *foldingRanges) add(r taskfile.Range, kind protocol.FoldingRangeKind) {
+	key := [2]int{r[0], r[2]}
+	if r[2] <= r[0] || f.seen[key] {
+		return
+	}
+	f.seen[key] = true
+	f.ranges = append(f.ranges, protocol.FoldingRange{StartLine: r[0], EndLine: r[2], Kind: kind})
+}
+
+func (t *TaskfileExtension) TextDocumentFoldingRange(params *protocol.FoldingRangeParams) ([]protocol.FoldingRange, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	folds := &foldingRanges{ranges: make([]protocol.FoldingRange, 0), seen: make(map[[2]int]bool)}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return folds.ranges, nil
+	}
+	for _, b := range tf.Blocks {
+		folds.add(b.Range, "")
+	}
+	for _, s := range tf.Scalars {
+		folds.add(s, "")
+	}
+	for _, task := range tf.Tasks {
+		folds.add(task.Range, "")
+		for _, b := range task.Blocks {
+			folds.add(b.Range, "")
+		}
+		for _, s := range task.Scalars {
+			folds.add(s, "")
+		}
+	}
+	for _, c := range tf.CommentBlocks() {
+		folds.add(c, protocol.CommentFoldingRange)
+	}
+	return folds.ranges, nil
+}
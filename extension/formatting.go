@@ -0,0 +1,114 @@
+package extension
+
+import (
+	"strings"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// MaxDiffSize is the maximum number of line comparisons made to compute minimal edits
+// Bigger documents are replaced in a single edit
+const MaxDiffSize = 4000000
+
+func (t *TaskfileExtension) TextDocumentFormatting(params *lsp.DocumentFormattingParams) ([]lsp.TextEdit, *jsonrpc.ResponseError) {
+	return t.format(params.TextDocument.URI, params.Options, nil)
+}
+
+func (t *TaskfileExtension) TextDocumentRangeFormatting(params *lsp.DocumentRangeFormattingParams) ([]lsp.TextEdit, *jsonrpc.ResponseError) {
+	return t.format(params.TextDocument.URI, params.Options, &params.Range)
+}
+
+// format returns the edits formatting a document
+// If a range is provided, only the edits touching the lines of the range are returned
+func (t *TaskfileExtension) format(uri lsp.DocumentURI, options lsp.FormattingOptions, r *lsp.Range) ([]lsp.TextEdit, *jsonrpc.ResponseError) {
+	text, err := t.documentText(uri)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	formatted, err := taskfile.Format(text, taskfile.FormatOptions{
+		TabSize:   options.TabSize,
+		SortTasks: t.settings.Formatting.SortTasks,
+	})
+	if err != nil {
+		return nil, jsonrpc.NewError(protocol.RequestFailed, err.Error(), nil)
+	}
+	edits := make([]lsp.TextEdit, 0)
+	for _, e := range LineEdits(text, formatted, t.taskfiles.Encoding()) {
+		if r == nil || (e.Range.Start.Line <= r.End.Line && e.Range.End.Line >= r.Start.Line) {
+			edits = append(edits, e)
+		}
+	}
+	return edits, nil
+}
+
+// documentText returns the content of a document as seen by the editor
+// or the content of the file if the document is not open
+func (t *TaskfileExtension) documentText(uri lsp.DocumentURI) (string, error) {
+	p, err := GetPath(uri)
+	if err != nil {
+		return "", err
+	}
+	return t.taskfiles.Text(p)
+}
+
+// LineEdits computes the edits replacing the lines of a text to get a new text
+// The positions of the edits use the given encoding
+func LineEdits(before string, after string, enc taskfile.PositionEncoding) []lsp.TextEdit {
+	src := taskfile.NewSource(before, enc)
+	a := strings.SplitAfter(before, "\n")
+	b := strings.SplitAfter(after, "\n")
+	// Position of the start of a line of the original text
+	position := func(line int) lsp.Position {
+		if line < len(a) {
+			l, c := src.Position(src.Offset(line, 0))
+			return lsp.Position{Line: l, Character: c}
+		}
+		l, c := src.Position(len(before))
+		return lsp.Position{Line: l, Character: c}
+	}
+	if len(a)*len(b) > MaxDiffSize {
+		return []lsp.TextEdit{{Range: lsp.Range{Start: position(0), End: position(len(a))}, NewText: after}}
+	}
+	// Longest common subsequence of lines, lcs[i][j] is the length for a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	edits := make([]lsp.TextEdit, 0)
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		// Collect a hunk of removed and added lines
+		startA, startB := i, j
+		for (i < len(a) || j < len(b)) && !(i < len(a) && j < len(b) && a[i] == b[j]) {
+			if j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]) {
+				i++
+			} else {
+				j++
+			}
+		}
+		edits = append(edits, lsp.TextEdit{
+			Range:   lsp.Range{Start: position(startA), End: position(i)},
+			NewText: strings.Join(b[startB:j], ""),
+		})
+	}
+	return edits
+}
@@ -0,0 +1,41 @@
+package extension
+
+import (
+	"reflect"
+	"taskfile-language-server/taskfile"
+	"testing"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+func edit(l1, c1, l2, c2 int, text string) lsp.TextEdit {
+	return lsp.TextEdit{
+		Range:   lsp.Range{Start: lsp.Position{Line: l1, Character: c1}, End: lsp.Position{Line: l2, Character: c2}},
+		NewText: text,
+	}
+}
+
+func TestLineEdits(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		enc    taskfile.PositionEncoding
+		want   []lsp.TextEdit
+	}{
+		{"same text", "a\nb\n", "a\nb\n", taskfile.UTF16, []lsp.TextEdit{}},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", taskfile.UTF16, []lsp.TextEdit{edit(1, 0, 2, 0, "B\n")}},
+		{"added line", "a\nc\n", "a\nb\nc\n", taskfile.UTF16, []lsp.TextEdit{edit(1, 0, 1, 0, "b\n")}},
+		{"removed line", "a\nb\nc\n", "a\nc\n", taskfile.UTF16, []lsp.TextEdit{edit(1, 0, 2, 0, "")}},
+		{"final line without newline", "a\nb", "a\nb\n", taskfile.UTF16, []lsp.TextEdit{edit(1, 0, 1, 1, "b\n")}},
+		// The end of the text is encoded like the other positions
+		{"final multibyte line", "a\né😀", "a\nb\n", taskfile.UTF16, []lsp.TextEdit{edit(1, 0, 1, 3, "b\n")}},
+		{"final multibyte line in utf-8", "a\né😀", "a\nb\n", taskfile.UTF8, []lsp.TextEdit{edit(1, 0, 1, 6, "b\n")}},
+		{"crlf line endings", "a\r\nb\r\n", "a\r\nB\r\n", taskfile.UTF16, []lsp.TextEdit{edit(1, 0, 2, 0, "B\r\n")}},
+	}
+	for _, tt := range tests {
+		if got := LineEdits(tt.before, tt.after, tt.enc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: LineEdits() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package extension
+
+import (
+	"fmt"
+	"taskfile-language-server/jsonrpc"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// valueMarkdown renders a value, or why it is unknown
+func valueMarkdown(value taskfile.Value) string {
+	if value.Unknown {
+		return fmt.Sprintf("*<unknown>* %s", value.Reason)
+	}
+	return fmt.Sprintf("```sh\n%s\n```", value.Text)
+}
+
+// TextDocumentHover previews the value of the expression at a position, or the rendered shell command
+func (t *TaskfileExtension) TextDocumentHover(params *lsp.TextDocumentPositionParams) (*lsp.Hover, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return nil, nil
+	}
+	line, col := params.Position.Line, params.Position.Character
+	task := tf.TaskAtPosition(line, col)
+	exprs := tf.Expressions
+	if task != nil {
+		exprs = task.Expressions
+	}
+	for _, e := range exprs {
+		if !taskfile.IsInRange(line, col, e.Range) {
+			continue
+		}
+		value, ok := tf.EvalExpr(task, e)
+		if !ok {
+			return nil, nil
+		}
+		r := ToLSPRange(e.Range)
+		return &lsp.Hover{Contents: []lsp.MarkedString{lsp.RawMarkedString(valueMarkdown(value))}, Range: &r}, nil
+	}
+	if task == nil {
+		return nil, nil
+	}
+	cmd := task.CommandAtPosition(line, col)
+	if cmd == nil {
+		return nil, nil
+	}
+	value, tplErr := tf.Render(task, cmd.Value)
+	contents := valueMarkdown(value)
+	if tplErr != nil {
+		contents = fmt.Sprintf("Can not render the command: %s", tplErr.Error())
+	}
+	r := ToLSPRange(cmd.Range)
+	return &lsp.Hover{Contents: []lsp.MarkedString{lsp.RawMarkedString(contents)}, Range: &r}, nil
+}
@@ -2,21 +2,47 @@ package extension
 
 import (
 	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
 
 	"github.com/sourcegraph/go-lsp"
 )
 
-func (t *TaskfileExtension) Initialize(params *lsp.InitializeParams) (*lsp.InitializeResult, *jsonrpc.ResponseError) {
-	caps := lsp.ServerCapabilities{
-		CompletionProvider: &lsp.CompletionOptions{ResolveProvider: true},
-		TextDocumentSync: &lsp.TextDocumentSyncOptionsOrKind{
-			Options: &lsp.TextDocumentSyncOptions{
-				OpenClose: true,
-				Change:    lsp.TDSKFull,
+func (t *TaskfileExtension) Initialize(params *protocol.InitializeParams) (*protocol.InitializeResult, *jsonrpc.ResponseError) {
+	// Every range of the Taskfiles of the store will use the encoding picked here
+	t.taskfiles.SetEncoding(taskfile.NegotiateEncoding(params.Capabilities.General.PositionEncodings))
+	t.settings = GetSettings(params.InitializationOptions)
+	caps := protocol.ServerCapabilities{
+		ServerCapabilities: lsp.ServerCapabilities{
+			CompletionProvider:              &lsp.CompletionOptions{ResolveProvider: true},
+			DocumentFormattingProvider:      true,
+			DocumentRangeFormattingProvider: true,
+			CodeLensProvider:                &lsp.CodeLensOptions{},
+			ExecuteCommandProvider:          &lsp.ExecuteCommandOptions{Commands: Commands},
+			CodeActionProvider:              true,
+			DocumentHighlightProvider:       true,
+			RenameProvider:                  true,
+			HoverProvider:                   true,
+			TextDocumentSync: &lsp.TextDocumentSyncOptionsOrKind{
+				Options: &lsp.TextDocumentSyncOptions{
+					OpenClose: true,
+					Change:    lsp.TDSKIncremental,
+				},
 			},
 		},
+		PositionEncoding:       string(t.taskfiles.Encoding()),
+		FoldingRangeProvider:   true,
+		DocumentLinkProvider:   &protocol.DocumentLinkOptions{},
+		InlayHintProvider:      true,
+		CallHierarchyProvider:  true,
+		SelectionRangeProvider: true,
+		SemanticTokensProvider: &protocol.SemanticTokensOptions{
+			Legend: SemanticTokensLegend,
+			Range:  true,
+			Full:   true,
+		},
 	}
-	return &lsp.InitializeResult{Capabilities: caps}, nil
+	return &protocol.InitializeResult{Capabilities: caps}, nil
 }
 
 func (t *TaskfileExtension) Initialized() *jsonrpc.ResponseError {
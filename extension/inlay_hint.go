@@ -0,0 +1,86 @@
+package extension
+
+import (
+	"strings"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// MaxHintLength is the number of characters of a value shown in a hint, the tooltip holds the whole value
+const MaxHintLength = 40
+
+// hintLabel returns the label showing a value on a single line
+func hintLabel(value taskfile.Value) string {
+	if value.Unknown {
+		return "= <unknown>"
+	}
+	if value.Text == "" {
+		return `= ""`
+	}
+	text := strings.Replace(value.Text, "\n", "⏎", -1)
+	if utf8.RuneCountInString(text) > MaxHintLength {
+		text = string([]rune(text)[:MaxHintLength-1]) + "…"
+	}
+	return "= " + text
+}
+
+// hintPosition returns the position following the `}}` closing an expression
+func hintPosition(src *taskfile.Source, r taskfile.Range) (lsp.Position, bool) {
+	line := src.Line(r[2])
+	col := taskfile.DecodeColumn(line, r[3], src.Encoding)
+	end := strings.Index(line[col:], "}}")
+	if end < 0 {
+		return lsp.Position{}, false
+	}
+	return lsp.Position{Line: r[2], Character: src.Column(r[2], col+end+2)}, true
+}
+
+// expressionHints returns the hints of the expressions of a task in a range
+// The variables of the task are only resolved if one of its expressions is in the range
+func expressionHints(tf *taskfile.Taskfile, task *taskfile.Task, exprs []taskfile.Expr, r lsp.Range) []protocol.InlayHint {
+	hints := make([]protocol.InlayHint, 0)
+	var ev *taskfile.Evaluator
+	for _, e := range exprs {
+		if e.Range[2] < r.Start.Line || e.Range[0] > r.End.Line {
+			continue
+		}
+		if ev == nil {
+			ev = tf.NewEvaluator(task)
+		}
+		value, ok := ev.Eval(e)
+		if !ok {
+			continue
+		}
+		pos, ok := hintPosition(tf.Source, e.Range)
+		if !ok {
+			continue
+		}
+		hint := protocol.InlayHint{Position: pos, Label: hintLabel(value), PaddingLeft: true, Tooltip: value.Text}
+		if value.Unknown {
+			hint.Tooltip = value.Reason
+		}
+		hints = append(hints, hint)
+	}
+	return hints
+}
+
+// TextDocumentInlayHint shows the value of the expressions which can be resolved without running Task
+func (t *TaskfileExtension) TextDocumentInlayHint(params *protocol.InlayHintParams) ([]protocol.InlayHint, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return make([]protocol.InlayHint, 0), nil
+	}
+	hints := expressionHints(tf, nil, tf.Expressions, params.Range)
+	for _, task := range tf.Tasks {
+		hints = append(hints, expressionHints(tf, task, task.Expressions, params.Range)...)
+	}
+	return hints, nil
+}
@@ -0,0 +1,280 @@
+package extension
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// InlinableProperties are the properties of a task that can be inlined
+// Any other property changes how the commands are run
+var InlinableProperties = map[string]bool{"cmds": true, "vars": true, "desc": true, "summary": true}
+
+// TaskfileProperties are the properties of a Taskfile changing how its commands run
+// The commands of a Taskfile setting one of them are not moved to another Taskfile
+var TaskfileProperties = []string{"env", "dotenv", "set", "shopt"}
+
+// LocationVars are the special variables whose value depends on the Taskfile of a task
+var LocationVars = map[string]bool{"TASKFILE": true, "TASKFILE_DIR": true, "TASK_DIR": true}
+
+// callSite is a `- task:` command calling the inlined task, possibly from another Taskfile
+type callSite struct {
+	taskfile *taskfile.Taskfile
+	caller   *taskfile.Task
+	call     taskfile.Call
+	cmd      taskfile.Command
+}
+
+// inlineAction replaces the calls of the task defined at the start of a range by its commands
+func inlineAction(tf *taskfile.Taskfile, uri lsp.DocumentURI, r lsp.Range) *protocol.CodeAction {
+	for _, task := range tf.Tasks {
+		if task.NameRange[0] != r.Start.Line {
+			continue
+		}
+		changes := inlineTask(tf, task)
+		if changes == nil {
+			return nil
+		}
+		return &protocol.CodeAction{
+			Title: fmt.Sprintf("Inline task %s", task.Name),
+			Kind:  lsp.CAKRefactorInline,
+			Edit:  &lsp.WorkspaceEdit{Changes: changes},
+		}
+	}
+	return nil
+}
+
+// callSites returns the commands calling a task, from the Taskfile and the Taskfiles known by the store
+// Returns false if a call can not be replaced by the commands: dependencies, deferred calls, calls of the
+// task itself, calls from a task running in another directory or from another Taskfile the commands depend on
+func callSites(tf *taskfile.Taskfile, task *taskfile.Task) ([]callSite, bool) {
+	sites := make([]callSite, 0)
+	for _, d := range tf.Dependents(task.Name) {
+		same := d.Taskfile.Path == tf.Path
+		if (same && d.Task.Name == task.Name) || d.Task.CmdsFlow || d.Task.Dir != nil {
+			return nil, false
+		}
+		for _, c := range d.Calls {
+			if c.Dep || d.Task.Commands[c.Command].Task == nil {
+				return nil, false
+			}
+			site := callSite{taskfile: d.Taskfile, caller: d.Task, call: c, cmd: d.Task.Commands[c.Command]}
+			if !same && !movable(tf, task, site) {
+				return nil, false
+			}
+			sites = append(sites, site)
+		}
+	}
+	sort.SliceStable(sites, func(i, j int) bool {
+		a, b := sites[i], sites[j]
+		return a.taskfile.Path < b.taskfile.Path || a.taskfile.Path == b.taskfile.Path && a.cmd.Range[0] < b.cmd.Range[0]
+	})
+	return sites, true
+}
+
+// movable returns true if the commands of a task run the same in the Taskfile of a call site:
+// they call no task, the Taskfiles run them in the same directory, and their variables are passed
+// by the call or declared by the task
+func movable(tf *taskfile.Taskfile, task *taskfile.Task, site callSite) bool {
+	if len(task.Calls()) > 0 {
+		return false
+	}
+	for _, key := range TaskfileProperties {
+		if _, ok := tf.Entries[key]; ok {
+			return false
+		}
+	}
+	for _, root := range site.taskfile.Roots() {
+		dir, ok := taskDir(root, tf, task)
+		callerDir, callerOk := taskDir(root, site.taskfile, site.caller)
+		if !ok || !callerOk || dir != callerDir {
+			return false
+		}
+	}
+	for _, e := range task.Expressions {
+		for _, tk := range e.Tokens {
+			name := tk.FieldName()
+			if name == "" {
+				continue
+			}
+			if _, ok := site.call.Vars[name]; ok {
+				continue
+			}
+			if _, ok := task.Vars[name]; ok {
+				continue
+			}
+			if _, ok := tf.Vars[name]; ok || LocationVars[name] {
+				return false
+			}
+			if _, ok := tf.DotenvVars(task)[name]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// taskDir returns the directory a task runs in when a root Taskfile runs it, false if the root does not include it
+func taskDir(root *taskfile.Taskfile, tf *taskfile.Taskfile, task *taskfile.Task) (string, bool) {
+	if root.Path == tf.Path {
+		return filepath.Dir(root.Path), true
+	}
+	included, _ := root.Included()
+	for _, it := range included {
+		if it.Taskfile.Path != tf.Path || it.Task.Name != task.Name {
+			continue
+		}
+		for _, include := range it.Includes {
+			// The variables of the includes are not passed to the Taskfile of the call site
+			if len(include.Vars) > 0 {
+				return "", false
+			}
+		}
+		return it.Dir, true
+	}
+	return "", false
+}
+
+// varValue returns the source of the value of a variable written on a single line
+func varValue(src *taskfile.Source, v *taskfile.Var) (string, bool) {
+	r := taskfile.Range{v.NameRange[2], v.NameRange[3], v.Range[2], v.Range[3]}
+	if r[0] != r[2] {
+		return "", false
+	}
+	value := strings.TrimSpace(textOf(src, r))
+	return strings.TrimSpace(strings.TrimPrefix(value, ":")), true
+}
+
+// inlineVars collects the declarations a caller needs to run the inlined commands
+// The values passed by the call come first, then the values declared by the task
+// Returns false if a variable of the caller has another value
+func inlineVars(tf *taskfile.Taskfile, task *taskfile.Task, site callSite, declared map[string]string) bool {
+	for _, e := range task.Expressions {
+		for _, tk := range e.Tokens {
+			name := tk.FieldName()
+			src := site.taskfile.Source
+			v, ok := site.call.Vars[name]
+			if !ok {
+				src = tf.Source
+				v, ok = task.Vars[name]
+			}
+			if name == "" || !ok {
+				continue
+			}
+			value, ok := varValue(src, v)
+			if !ok {
+				return false
+			}
+			if existing, ok := site.caller.Vars[name]; ok {
+				current, _ := varValue(site.taskfile.Source, existing)
+				if current != value {
+					return false
+				}
+				continue
+			}
+			if previous, ok := declared[name]; ok && previous != value {
+				return false
+			}
+			declared[name] = value
+		}
+	}
+	return true
+}
+
+// inlineTask replaces each call of a task by its commands and removes the task
+// The edits of the Taskfiles of the call sites are indexed by URI, nil is returned if a call can not be replaced
+func inlineTask(tf *taskfile.Taskfile, task *taskfile.Task) map[string][]lsp.TextEdit {
+	src := tf.Source
+	for key := range task.Keys {
+		if !InlinableProperties[key] {
+			return nil
+		}
+	}
+	if task.CmdsFlow || task.CmdsString || len(task.Commands) == 0 {
+		return nil
+	}
+	sites, ok := callSites(tf, task)
+	if !ok || len(sites) == 0 {
+		return nil
+	}
+	first, last := task.Commands[0].Range, task.Commands[len(task.Commands)-1].Range
+	line := src.Line(first[0])
+	if strings.TrimSpace(line[:taskfile.DecodeColumn(line, first[1], src.Encoding)]) != "-" {
+		return nil
+	}
+	itemIndent := taskfile.Indentation(line)
+	body := make([]string, 0)
+	for l := first[0]; l <= last[2]; l++ {
+		body = append(body, src.Line(l))
+	}
+	changes := make(map[string][]lsp.TextEdit)
+	callers := make([]callSite, 0)
+	declarations := make(map[*taskfile.Task]map[string]string)
+	for _, site := range sites {
+		if declarations[site.caller] == nil {
+			declarations[site.caller] = make(map[string]string)
+			callers = append(callers, site)
+		}
+		if !inlineVars(tf, task, site, declarations[site.caller]) {
+			return nil
+		}
+		siteSrc := site.taskfile.Source
+		shift := taskfile.Indentation(siteSrc.Line(site.cmd.Range[0])) - itemIndent
+		uri := string(GetURI(site.taskfile.Path))
+		changes[uri] = append(changes[uri], replaceLines(siteSrc, site.cmd.Range[0], site.cmd.Range[2], shiftBlock(body, shift)))
+	}
+	for _, site := range callers {
+		vars := declarations[site.caller]
+		if len(vars) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines := make([]string, len(names))
+		for i, name := range names {
+			lines[i] = fmt.Sprintf("%s: %s", name, vars[name])
+		}
+		edit := defineVars(site.taskfile, site.caller, lines)
+		if edit == nil {
+			return nil
+		}
+		uri := string(GetURI(site.taskfile.Path))
+		changes[uri] = append(changes[uri], *edit)
+	}
+	// Remove the task and the empty line separating it from the previous entry
+	start := task.Range[0]
+	if start > 0 && strings.TrimSpace(src.Line(start-1)) == "" {
+		start--
+	}
+	uri := string(GetURI(tf.Path))
+	changes[uri] = append(changes[uri], replaceLines(src, start, task.Range[2], nil))
+	return changes
+}
+
+// replaceLines replaces whole lines of the source, from the line start to the line end included
+func replaceLines(src *taskfile.Source, start int, end int, lines []string) lsp.TextEdit {
+	if end+1 < src.LineCount() {
+		text := ""
+		if len(lines) > 0 {
+			text = strings.Join(lines, "\n") + "\n"
+		}
+		return lsp.TextEdit{
+			Range:   lsp.Range{Start: lsp.Position{Line: start}, End: lsp.Position{Line: end + 1}},
+			NewText: text,
+		}
+	}
+	// The last line has no line break, remove the one of the previous line instead
+	r := src.NewRange(start, 0, end, len(src.Line(end)))
+	if len(lines) == 0 && start > 0 {
+		r = src.NewRange(start-1, len(src.Line(start-1)), end, len(src.Line(end)))
+	}
+	return lsp.TextEdit{Range: ToLSPRange(r), NewText: strings.Join(lines, "\n")}
+}
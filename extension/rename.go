@@ -0,0 +1,80 @@
+package extension
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// taskName matches the names a task can be renamed to, a colon would call a namespace
+var taskName = regexp.MustCompile(`^[\w.-]+$`)
+
+// TextDocumentRename renames the task at a position, its declaration and its calls from the Taskfiles known by the store
+// The calls through an alias of the task are left as they are
+func (t *TaskfileExtension) TextDocumentRename(params *lsp.RenameParams) (*lsp.WorkspaceEdit, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return nil, jsonrpc.NewError(protocol.RequestFailed, "The Taskfile can not be parsed", nil)
+	}
+	sym, ok := tf.SymbolAt(params.Position.Line, params.Position.Character)
+	if !ok || sym.Kind != taskfile.SymbolTask {
+		return nil, jsonrpc.NewError(protocol.RequestFailed, "Only tasks can be renamed", nil)
+	}
+	target, task := tf.ResolveCall(sym.Name)
+	if task == nil {
+		return nil, jsonrpc.NewError(protocol.RequestFailed, fmt.Sprintf("Task %s does not exist", sym.Name), nil)
+	}
+	if !taskName.MatchString(params.NewName) {
+		return nil, jsonrpc.NewError(jsonrpc.InvalidParams, fmt.Sprintf("%s is not a valid task name", params.NewName), nil)
+	}
+	if _, exists := target.Tasks[params.NewName]; exists {
+		return nil, jsonrpc.NewError(protocol.RequestFailed, fmt.Sprintf("Task %s already exists", params.NewName), nil)
+	}
+	changes := make(map[string][]lsp.TextEdit)
+	if r, ok := nameRange(target.Source, task.NameRange, task.Name); ok {
+		uri := string(GetURI(target.Path))
+		changes[uri] = append(changes[uri], lsp.TextEdit{Range: ToLSPRange(r), NewText: params.NewName})
+	}
+	for _, d := range target.Dependents(task.Name) {
+		uri := string(GetURI(d.Taskfile.Path))
+		for _, c := range d.Calls {
+			// The namespace of the call is kept
+			if c.Task != task.Name && !strings.HasSuffix(c.Task, ":"+task.Name) {
+				continue
+			}
+			if r, ok := nameRange(d.Taskfile.Source, c.Range, c.Task); ok {
+				newName := strings.TrimSuffix(c.Task, task.Name) + params.NewName
+				changes[uri] = append(changes[uri], lsp.TextEdit{Range: ToLSPRange(r), NewText: newName})
+			}
+		}
+	}
+	return &lsp.WorkspaceEdit{Changes: changes}, nil
+}
+
+// nameRange returns the part of a range holding a name, the quotes around the name are left out
+// Returns false if the range does not hold the name alone
+func nameRange(src *taskfile.Source, r taskfile.Range, name string) (taskfile.Range, bool) {
+	if len(r) != 4 || r[0] != r[2] {
+		return nil, false
+	}
+	line := src.Line(r[0])
+	start := taskfile.DecodeColumn(line, r[1], src.Encoding)
+	end := taskfile.DecodeColumn(line, r[3], src.Encoding)
+	text := line[start:end]
+	if len(text) == len(name)+2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		start, end = start+1, end-1
+	}
+	if line[start:end] != name {
+		return nil, false
+	}
+	return src.NewRange(r[0], start, r[0], end), true
+}
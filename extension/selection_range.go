@@ -0,0 +1,33 @@
+package extension
+
+import (
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+)
+
+// TextDocumentSelectionRange expands the selection from the word at each position to the enclosing nodes of the Taskfile
+// A position outside of any node selects itself, the result holds one selection range per position
+func (t *TaskfileExtension) TextDocumentSelectionRange(params *protocol.SelectionRangeParams) ([]protocol.SelectionRange, *jsonrpc.ResponseError) {
+	p, err := GetPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tf := t.taskfiles.Get(p)
+	selections := make([]protocol.SelectionRange, len(params.Positions))
+	for i, pos := range params.Positions {
+		selections[i].Range.Start = pos
+		selections[i].Range.End = pos
+		if tf == nil {
+			continue
+		}
+		ranges := tf.SelectionRanges(pos.Line, pos.Character)
+		var parent *protocol.SelectionRange
+		for j := len(ranges) - 1; j >= 0; j-- {
+			parent = &protocol.SelectionRange{Range: ToLSPRange(ranges[j]), Parent: parent}
+		}
+		if parent != nil {
+			selections[i] = *parent
+		}
+	}
+	return selections, nil
+}
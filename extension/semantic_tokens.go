@@ -0,0 +1,162 @@
+package extension
+
+import (
+	"sort"
+	"taskfile-language-server/jsonrpc"
+	protocol "taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// Indices of the token types in the legend
+const (
+	TokenTypeTask = iota
+	TokenTypeVariable
+	TokenTypeFunction
+	TokenTypeKeyword
+	TokenTypeOperator
+	TokenTypeString
+	TokenTypeNumber
+	TokenTypeComment
+)
+
+// Bits of the token modifiers in the legend
+const (
+	TokenModifierDeclaration = 1 << iota
+	TokenModifierDefaultLibrary
+)
+
+var SemanticTokensLegend = protocol.SemanticTokensLegend{
+	TokenTypes:     []string{"function", "variable", "macro", "keyword", "operator", "string", "number", "comment"},
+	TokenModifiers: []string{"declaration", "defaultLibrary"},
+}
+
+type semanticToken struct {
+	line      int
+	col       int
+	length    int
+	kind      int
+	modifiers int
+}
+
+// semanticTokens collects the tokens of a document, tokens spanning multiple lines are ignored
+type semanticTokens struct {
+	tokens []semanticToken
+	// globals are the variables available to every Taskfile, their functions are from the default library
+	globals map[string]*taskfile.Var
+}
+
+func (s *semanticTokens) add(r taskfile.Range, kind int, modifiers int) {
+	if len(r) != 4 || r[0] != r[2] || r[3] <= r[1] {
+		return
+	}
+	s.tokens = append(s.tokens, semanticToken{line: r[0], col: r[1], length: r[3] - r[1], kind: kind, modifiers: modifiers})
+}
+
+func (s *semanticTokens) addExpressions(exprs []taskfile.Expr) {
+	for _, e := range exprs {
+		for _, tk := range e.Tokens {
+			switch tk.Kind {
+			case taskfile.TokenField:
+				modifiers := 0
+				if taskfile.SpecialVars[tk.FieldName()] {
+					modifiers = TokenModifierDefaultLibrary
+				}
+				s.add(tk.Range, TokenTypeVariable, modifiers)
+			case taskfile.TokenVariable:
+				s.add(tk.Range, TokenTypeVariable, 0)
+			case taskfile.TokenFunction:
+				modifiers := 0
+				if _, ok := s.globals[tk.Value]; ok {
+					modifiers = TokenModifierDefaultLibrary
+				}
+				s.add(tk.Range, TokenTypeFunction, modifiers)
+			case taskfile.TokenKeyword:
+				s.add(tk.Range, TokenTypeKeyword, 0)
+			case taskfile.TokenPipe, taskfile.TokenOperator:
+				s.add(tk.Range, TokenTypeOperator, 0)
+			case taskfile.TokenString:
+				s.add(tk.Range, TokenTypeString, 0)
+			case taskfile.TokenNumber:
+				s.add(tk.Range, TokenTypeNumber, 0)
+			case taskfile.TokenComment:
+				s.add(tk.Range, TokenTypeComment, 0)
+			}
+		}
+	}
+}
+
+func (s *semanticTokens) addVars(vars map[string]*taskfile.Var) {
+	for _, v := range vars {
+		s.add(v.NameRange, TokenTypeVariable, TokenModifierDeclaration)
+	}
+}
+
+// encode sorts the tokens and encodes them relative to each other
+// Only the tokens overlapping the given range are kept if it is not nil
+func (s *semanticTokens) encode(r *lsp.Range) []int {
+	sort.Slice(s.tokens, func(i, j int) bool {
+		if s.tokens[i].line != s.tokens[j].line {
+			return s.tokens[i].line < s.tokens[j].line
+		}
+		return s.tokens[i].col < s.tokens[j].col
+	})
+	data := make([]int, 0, len(s.tokens)*5)
+	line, col := 0, 0
+	for _, tk := range s.tokens {
+		if r != nil && !tokenInRange(tk, *r) {
+			continue
+		}
+		if tk.line != line {
+			col = 0
+		}
+		data = append(data, tk.line-line, tk.col-col, tk.length, tk.kind, tk.modifiers)
+		line, col = tk.line, tk.col
+	}
+	return data
+}
+
+func tokenInRange(tk semanticToken, r lsp.Range) bool {
+	if tk.line < r.Start.Line || tk.line > r.End.Line {
+		return false
+	}
+	if tk.line == r.Start.Line && tk.col+tk.length <= r.Start.Character {
+		return false
+	}
+	if tk.line == r.End.Line && tk.col >= r.End.Character {
+		return false
+	}
+	return true
+}
+
+func (t *TaskfileExtension) semanticTokens(uri lsp.DocumentURI, r *lsp.Range) (*protocol.SemanticTokens, *jsonrpc.ResponseError) {
+	p, err := GetPath(uri)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
+	}
+	tokens := &semanticTokens{tokens: make([]semanticToken, 0), globals: t.taskfiles.Globals()}
+	tf := t.taskfiles.Get(p)
+	if tf == nil {
+		return &protocol.SemanticTokens{Data: tokens.encode(r)}, nil
+	}
+	tokens.addVars(tf.Vars)
+	tokens.addExpressions(tf.Expressions)
+	for _, task := range tf.Tasks {
+		tokens.add(task.NameRange, TokenTypeTask, TokenModifierDeclaration)
+		tokens.addVars(task.Vars)
+		tokens.addExpressions(task.Expressions)
+		for _, c := range task.Calls() {
+			tokens.add(c.Range, TokenTypeTask, 0)
+		}
+	}
+	return &protocol.SemanticTokens{Data: tokens.encode(r)}, nil
+}
+
+func (t *TaskfileExtension) TextDocumentSemanticTokensFull(params *protocol.SemanticTokensParams) (*protocol.SemanticTokens, *jsonrpc.ResponseError) {
+	return t.semanticTokens(params.TextDocument.URI, nil)
+}
+
+func (t *TaskfileExtension) TextDocumentSemanticTokensRange(params *protocol.SemanticTokensRangeParams) (*protocol.SemanticTokens, *jsonrpc.ResponseError) {
+	return t.semanticTokens(params.TextDocument.URI, &params.Range)
+}
@@ -0,0 +1,37 @@
+package extension
+
+import "encoding/json"
+
+// Settings are provided by the client in the initialization options
+type Settings struct {
+	Formatting FormattingSettings `json:"formatting"`
+	Run        RunSettings        `json:"run"`
+}
+
+type FormattingSettings struct {
+	// Sort the tasks by name when formatting a Taskfile
+	SortTasks bool `json:"sortTasks"`
+}
+
+type RunSettings struct {
+	// Path of the task binary used to run the tasks, defaults to `task`
+	Executable string `json:"executable"`
+}
+
+// GetSettings extracts the settings from the initialization options
+// Missing or invalid options fallback to the default settings
+func GetSettings(options interface{}) Settings {
+	settings := Settings{Run: RunSettings{Executable: "task"}}
+	if options == nil {
+		return settings
+	}
+	raw, err := json.Marshal(options)
+	if err != nil {
+		return settings
+	}
+	_ = json.Unmarshal(raw, &settings)
+	if settings.Run.Executable == "" {
+		settings.Run.Executable = "task"
+	}
+	return settings
+}
@@ -24,6 +24,25 @@ func GetTaskInfo(scope string, task *taskfile.Task) *TaskInfo {
 	}
 }
 
+// TaskInfos returns the tasks of a Taskfile, then the tasks of its includes that can be run from the command line
+func TaskInfos(scope string, tf *taskfile.Taskfile) []*TaskInfo {
+	tasks := make([]*TaskInfo, 0)
+	for _, t := range tf.Tasks {
+		tasks = append(tasks, GetTaskInfo(scope, t))
+	}
+	included, _ := tf.Included()
+	for _, it := range included {
+		if it.Internal {
+			continue
+		}
+		info := GetTaskInfo(scope, it.Task)
+		info.Task.Value = it.Name
+		info.Taskfile = it.Taskfile.Path
+		tasks = append(tasks, info)
+	}
+	return tasks
+}
+
 func (t *TaskfileExtension) GetTasks(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
 	parsed := &GetTasksParams{}
 
@@ -34,21 +53,10 @@ func (t *TaskfileExtension) GetTasks(params json.RawMessage) (interface{}, *json
 
 	path := filepath.ToSlash(parsed.FsPath)
 
-	tf := taskfile.GetParsedTaskfile(path)
+	tf := t.taskfiles.Get(path)
 	if tf == nil {
 		return nil, jsonrpc.NewError(jsonrpc.ParseError, "Could not find taskfile", nil)
 	}
 
-	tasks := make([]*TaskInfo, 0)
-
-	if tf.Tasks == nil {
-		return tasks, nil
-	}
-
-	for _, t := range tf.Tasks {
-		ti := GetTaskInfo(path, t)
-		tasks = append(tasks, ti)
-	}
-
-	return tasks, nil
+	return TaskInfos(path, tf), nil
 }
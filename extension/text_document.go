@@ -2,6 +2,9 @@ package extension
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"taskfile-language-server/jsonrpc"
 	"taskfile-language-server/taskfile"
 
@@ -9,33 +12,224 @@ import (
 )
 
 func (t *TaskfileExtension) TextDocumentDidOpen(params *lsp.DidOpenTextDocumentParams) {
-	err := reloadTaskfile(params.TextDocument.URI, params.TextDocument.Text)
+	doc := params.TextDocument
+	p, err := GetPath(doc.URI)
 	if err != nil {
 		t.Logger.Panicf(err.Error())
 	}
+	t.taskfiles.Open(p, doc.Version, doc.Text)
+	t.scheduleDiagnostics(doc.URI)
 }
 
 func (t *TaskfileExtension) TextDocumentDidChange(params *lsp.DidChangeTextDocumentParams) {
-	err := reloadTaskfile(params.TextDocument.URI, params.ContentChanges[0].Text)
+	uri := params.TextDocument.URI
+	p, err := GetPath(uri)
 	if err != nil {
 		t.Logger.Panicf(err.Error())
 	}
+	err = t.taskfiles.Change(p, params.TextDocument.Version, TextChanges(params.ContentChanges))
+	if outOfSync, ok := err.(*taskfile.ErrOutOfSync); ok {
+		// The text of the editor is unknown, nothing is answered for the document until it is opened again
+		t.Logger.Println(outOfSync.Error())
+		t.cancelDiagnostics(uri)
+		t.clearDiagnostics(uri)
+		t.ShowMessage(lsp.MTWarning, fmt.Sprintf("%s is out of sync with the server, close and open it again", filepath.Base(p)))
+		return
+	}
+	if err != nil {
+		t.Logger.Println(err.Error())
+		return
+	}
+	// The problems are checked once the changes settle, the read loop keeps answering the client
+	t.scheduleDiagnostics(uri)
+}
+
+// TextChanges converts the changes of a document to the changes of the store
+func TextChanges(events []lsp.TextDocumentContentChangeEvent) []taskfile.TextChange {
+	changes := make([]taskfile.TextChange, 0, len(events))
+	for _, e := range events {
+		change := taskfile.TextChange{Text: e.Text}
+		if e.Range != nil {
+			change.Range = taskfile.Range{e.Range.Start.Line, e.Range.Start.Character, e.Range.End.Line, e.Range.End.Character}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// publishRelatedDiagnostics updates the problems of the opened Taskfiles related to a changed Taskfile:
+// the Taskfiles including it, and the Taskfiles they include which see their variables and their calls
+func (t *TaskfileExtension) publishRelatedDiagnostics(uri lsp.DocumentURI) {
+	p, err := GetPath(uri)
+	if err != nil {
+		return
+	}
+	related := make(map[string]bool)
+	for _, root := range append(t.taskfiles.IncludersOf(p), p) {
+		related[root] = true
+		tf := t.taskfiles.Get(root)
+		if tf == nil {
+			continue
+		}
+		included, _ := tf.Included()
+		for _, it := range included {
+			related[it.Taskfile.Path] = true
+		}
+	}
+	delete(related, p)
+	paths := make([]string, 0, len(related))
+	for path := range related {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if t.taskfiles.IsOpen(path) {
+			t.publishDiagnostics(GetURI(path))
+		}
+	}
 }
 
-func (t *TaskfileExtension) TextDocumentDidClose(params *lsp.DidCloseTextDocumentParams) {}
+func (t *TaskfileExtension) TextDocumentDidClose(params *lsp.DidCloseTextDocumentParams) {
+	uri := params.TextDocument.URI
+	if p, err := GetPath(uri); err == nil {
+		t.taskfiles.Close(p)
+	}
+	t.clearDiagnostics(uri)
+	// The Taskfiles including the document see the file on disk again
+	t.scheduleDiagnostics(uri)
+}
 
-func CompletionItemFromVar(v *taskfile.Var, scoped bool) lsp.CompletionItem {
-	data := v.Name
+func CompletionItemFromVar(v *taskfile.Var, scoped bool, data CompletionItemData) lsp.CompletionItem {
+	insertText := v.Name
 	if scoped {
-		data = fmt.Sprintf(".%s", v.Name)
+		insertText = fmt.Sprintf(".%s", v.Name)
 	}
-	return lsp.CompletionItem{Label: v.Name, Kind: lsp.CIKVariable, InsertText: data}
+	data.Kind = KindVar
+	data.Name = v.Name
+	return lsp.CompletionItem{Label: v.Name, Kind: lsp.CIKVariable, InsertText: insertText, Data: data}
 }
 
-func CompletionItemsFromVars(vars map[string]*taskfile.Var, scoped bool) []lsp.CompletionItem {
+func CompletionItemsFromVars(vars map[string]*taskfile.Var, scoped bool, data CompletionItemData) []lsp.CompletionItem {
 	items := make([]lsp.CompletionItem, 0)
 	for _, v := range vars {
-		items = append(items, CompletionItemFromVar(v, scoped))
+		items = append(items, CompletionItemFromVar(v, scoped, data))
+	}
+	return items
+}
+
+// CompletionItemsFromEnv returns the environment variables, scoped items are inserted in a template
+func CompletionItemsFromEnv(env map[string]*taskfile.Var, scoped bool, data CompletionItemData) []lsp.CompletionItem {
+	items := make([]lsp.CompletionItem, 0)
+	for _, v := range env {
+		item := CompletionItemFromVar(v, scoped, data)
+		item.Kind = lsp.CIKConstant
+		item.Data = CompletionItemData{Path: data.Path, Scope: data.Scope, Task: data.Task, Kind: KindEnv, Name: v.Name}
+		items = append(items, item)
+	}
+	return items
+}
+
+// CompletionItemsFromDeclared returns the variables declared for a task, their values are resolved with the items
+func CompletionItemsFromDeclared(declared map[string]*taskfile.ResolvedVar, task *taskfile.Task, p string) []lsp.CompletionItem {
+	items := make([]lsp.CompletionItem, 0, len(declared))
+	for name, r := range declared {
+		v := &taskfile.Var{Name: name}
+		data := CompletionItemData{Path: p, Scope: ScopeTaskfile, Task: task.Name}
+		switch r.Source {
+		case taskfile.SourceEnviron:
+			continue
+		case taskfile.SourceSpecial:
+			data.Scope = ScopeGlobal
+		case taskfile.SourceTask:
+			data.Scope = ScopeTask
+		case taskfile.SourceDotenv:
+			data.Scope = ScopeDotenv
+		}
+		if r.Source == taskfile.SourceEnv || r.Source == taskfile.SourceDotenv {
+			items = append(items, CompletionItemsFromEnv(map[string]*taskfile.Var{name: v}, true, data)...)
+			continue
+		}
+		items = append(items, CompletionItemFromVar(v, true, data))
+	}
+	return items
+}
+
+// completionItemsFromTaskEnv returns the environment variables seen from a task
+func completionItemsFromTaskEnv(tf *taskfile.Taskfile, task *taskfile.Task, scoped bool, p string) []lsp.CompletionItem {
+	items := CompletionItemsFromEnv(task.Env, scoped, CompletionItemData{Path: p, Scope: ScopeTask, Task: task.Name})
+	items = append(items, CompletionItemsFromEnv(tf.Env, scoped, CompletionItemData{Path: p, Scope: ScopeTaskfile})...)
+	return append(items, CompletionItemsFromEnv(tf.DotenvVars(task), scoped, CompletionItemData{Path: p, Scope: ScopeDotenv, Task: task.Name})...)
+}
+
+var (
+	// A `$NAME` or `${NAME}` reference of a shell command
+	envPrefix = regexp.MustCompile(`\$\{?\w*$`)
+	// A `task:` entry of a command or of a dependency
+	callPrefix = regexp.MustCompile(`(^|[\s{,])task:\s*["']?[\w:.-]*$`)
+	// An item of a flow sequence of deps
+	flowDepsPrefix = regexp.MustCompile(`^\s*deps:\s*\[[^\]]*$`)
+	// An item of a block sequence
+	itemPrefix = regexp.MustCompile(`^\s*-\s*["']?[\w:.-]*$`)
+)
+
+// IsCallPosition returns true if the name of a task is expected at a position of a task
+func IsCallPosition(tf *taskfile.Taskfile, task *taskfile.Task, pos lsp.Position) bool {
+	line := tf.Source.Line(pos.Line)
+	prefix := line[:taskfile.DecodeColumn(line, pos.Character, tf.Source.Encoding)]
+	if callPrefix.MatchString(prefix) || flowDepsPrefix.MatchString(prefix) {
+		return true
+	}
+	for _, b := range task.Blocks {
+		if b.Name == "deps" && b.Range[0] < pos.Line && pos.Line <= b.Range[2] {
+			return itemPrefix.MatchString(prefix)
+		}
+	}
+	return false
+}
+
+// CompletionItemsFromFunctions returns the functions of Task and of text/template, sorted
+func CompletionItemsFromFunctions(p string) []lsp.CompletionItem {
+	names := make([]string, 0, len(taskfile.TaskFuncs)+len(taskfile.BuiltinFuncs))
+	for name := range taskfile.TaskFuncs {
+		names = append(names, name)
+	}
+	for name := range taskfile.BuiltinFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]lsp.CompletionItem, 0, len(names))
+	for _, name := range names {
+		data := CompletionItemData{Path: p, Scope: ScopeGlobal, Kind: KindFunction, Name: name}
+		items = append(items, lsp.CompletionItem{Label: name, Kind: lsp.CIKFunction, InsertText: name, Data: data})
+	}
+	return items
+}
+
+// IsEnvPosition returns true if the name of an environment variable is expected at a position of a shell command
+func IsEnvPosition(tf *taskfile.Taskfile, task *taskfile.Task, pos lsp.Position) bool {
+	if task.CommandAtPosition(pos.Line, pos.Character) == nil {
+		return false
+	}
+	line := tf.Source.Line(pos.Line)
+	prefix := line[:taskfile.DecodeColumn(line, pos.Character, tf.Source.Encoding)]
+	return envPrefix.MatchString(prefix)
+}
+
+// CompletionItemsFromTasks returns the tasks that can be called from a Taskfile, included tasks come with their namespace
+func CompletionItemsFromTasks(tf *taskfile.Taskfile, p string) []lsp.CompletionItem {
+	names := make([]string, 0, len(tf.Tasks))
+	for name := range tf.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	included, _ := tf.Included()
+	for _, it := range included {
+		names = append(names, it.Name)
+	}
+	items := make([]lsp.CompletionItem, 0, len(names))
+	for _, name := range names {
+		data := CompletionItemData{Path: p, Scope: ScopeTaskfile, Kind: KindTask, Name: name}
+		items = append(items, lsp.CompletionItem{Label: name, Kind: lsp.CIKFunction, InsertText: name, Data: data})
 	}
 	return items
 }
@@ -46,7 +240,7 @@ func (t *TaskfileExtension) TextDocumentCompletion(params *lsp.CompletionParams)
 		return nil, jsonrpc.NewError(jsonrpc.InternalError, err.Error(), nil)
 	}
 	empty := &lsp.CompletionList{Items: []lsp.CompletionItem{}, IsIncomplete: false}
-	tf := taskfile.GetParsedTaskfile(p)
+	tf := t.taskfiles.Get(p)
 	if tf == nil {
 		t.Logger.Printf("Could not find node tree for %s", p)
 		// No taskfile means the parsing went wrong. Maybe the user is stil typing
@@ -58,17 +252,30 @@ func (t *TaskfileExtension) TextDocumentCompletion(params *lsp.CompletionParams)
 		return empty, nil
 	}
 	exp := task.ExpressionAtPosition(params.Position.Line, params.Position.Character)
+	if exp == nil && IsEnvPosition(tf, task, params.Position) {
+		return &lsp.CompletionList{Items: completionItemsFromTaskEnv(tf, task, false, p), IsIncomplete: false}, nil
+	}
+	if exp == nil && IsCallPosition(tf, task, params.Position) {
+		return &lsp.CompletionList{Items: CompletionItemsFromTasks(tf, p), IsIncomplete: false}, nil
+	}
 	if exp == nil {
 		t.Logger.Println("Cursor is not in expression")
 		return empty, nil
 	}
-	items := make([]lsp.CompletionItem, 0)
-	// Add local variables
-	items = append(items, CompletionItemsFromVars(task.Vars, true)...)
-	// Add taskfile variables
-	items = append(items, CompletionItemsFromVars(tf.Vars, true)...)
+	if n := exp.NodeAt(params.Position.Line, params.Position.Character); n != nil && n.Kind == taskfile.NodeFunction {
+		return &lsp.CompletionList{Items: CompletionItemsFromFunctions(p), IsIncomplete: false}, nil
+	}
+	// Add the variables of the task, the declarations overridden by Task are left out
+	declared := tf.DeclaredVars(task)
+	items := CompletionItemsFromDeclared(declared, task, p)
+	// Add the environment of the task, it is not overridden by the variables
+	for name, v := range task.Env {
+		if _, ok := declared[name]; !ok {
+			items = append(items, CompletionItemsFromEnv(map[string]*taskfile.Var{name: v}, true, CompletionItemData{Path: p, Scope: ScopeTask, Task: task.Name})...)
+		}
+	}
 	// Add global variables
-	items = append(items, CompletionItemsFromVars(taskfile.Vars, false)...)
+	items = append(items, CompletionItemsFromVars(t.taskfiles.Globals(), false, CompletionItemData{Path: p, Scope: ScopeGlobal})...)
 
 	return &lsp.CompletionList{Items: items, IsIncomplete: false}, nil
 }
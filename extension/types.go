@@ -3,6 +3,8 @@ package extension
 type TaskInfo struct {
 	Task  TaskRef `json:"task"`
 	Scope string  `json:"scope"`
+	// Taskfile is the path of the Taskfile defining an included task, the range of the task is in this file
+	Taskfile string `json:"taskfile,omitempty"`
 }
 
 type TaskRef struct {
@@ -12,3 +14,31 @@ type TaskRef struct {
 	EndLine   int    `json:"endLine"`
 	EndCol    int    `json:"endCol"`
 }
+
+// CompletionItemData is attached to every completion item so the details
+// can be computed when the client resolves the item
+type CompletionItemData struct {
+	Path  string `json:"path"`
+	Scope string `json:"scope"`
+	Task  string `json:"task,omitempty"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+// Scopes a completion item can be defined in
+const (
+	ScopeGlobal   = "global"
+	ScopeTaskfile = "taskfile"
+	ScopeTask     = "task"
+	// ScopeDotenv is the scope of the variables of the dotenv files of a task or of the Taskfile
+	ScopeDotenv = "dotenv"
+)
+
+// Kinds of completion items
+const (
+	KindVar  = "var"
+	KindTask = "task"
+	KindEnv  = "env"
+	// KindFunction is a function of the templates
+	KindFunction = "function"
+)
@@ -1,8 +1,6 @@
 package extension
 
 import (
-	"taskfile-language-server/taskfile"
-
 	"github.com/sourcegraph/go-lsp"
 )
 
@@ -15,22 +13,36 @@ func (s *TaskfileExtension) WorkspaceDidChangeWatchedFiles(params *lsp.DidChange
 	for _, v := range params.Changes {
 		p, err := GetPath(v.URI)
 		if err != nil {
-			s.Logger.Fatalln(err)
+			s.Logger.Println(err.Error())
+			continue
+		}
+		// A changed dotenv file changes the variables of the Taskfiles referencing it
+		if s.taskfiles.InvalidateDotenv(p) {
+			for _, user := range s.taskfiles.DotenvUsers(p) {
+				if s.taskfiles.IsOpen(user) {
+					s.scheduleDiagnostics(GetURI(user))
+				}
+			}
+			continue
 		}
-		tf, err := taskfile.Preload(p)
+		// A file which can not be read is forgotten like a deleted one, the other changes are still handled
+		tf, err := s.taskfiles.Reload(p)
 		if err != nil {
-			s.Logger.Fatalln(err)
+			s.Logger.Println(err.Error())
+			continue
 		}
-		if tf.Tasks != nil {
-			tasks := make([]*TaskInfo, 0)
-			for _, t := range tf.Tasks {
-				tasks = append(tasks, GetTaskInfo(tf.Path, t))
-			}
+		if tf != nil && tf.Tasks != nil {
 			tfi := &TaskfileInfo{
 				Scope: tf.Path,
-				Tasks: tasks,
+				Tasks: TaskInfos(tf.Path, tf),
 			}
 			s.SendNotification("extension/onTaskfileUpdate", tfi)
 		}
+		// The tasks of the Taskfiles including the changed Taskfile changed too
+		for _, includer := range s.taskfiles.IncludersOf(p) {
+			if itf := s.taskfiles.Get(includer); itf != nil {
+				s.SendNotification("extension/onTaskfileUpdate", &TaskfileInfo{Scope: includer, Tasks: TaskInfos(includer, itf)})
+			}
+		}
 	}
 }
@@ -3,6 +3,7 @@ module taskfile-language-server
 go 1.13
 
 require (
+	github.com/go-task/slim-sprig/v3 v3.0.0
 	github.com/goccy/go-yaml v1.2.0
 	github.com/sourcegraph/go-lsp v0.0.0-20200117082640-b19bb38222e2
 )
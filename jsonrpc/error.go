@@ -12,6 +12,7 @@ const (
 	ServerErrorEnd       ErrorCode = -32000
 	ServerNotInitialized ErrorCode = -32002
 	UnknownErrorCode     ErrorCode = -32001
+	RequestCancelled     ErrorCode = -32800
 )
 
 type ResponseError struct {
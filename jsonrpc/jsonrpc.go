@@ -1,11 +1,13 @@
 package jsonrpc
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"sync"
 )
 
 type NotificationsProvider interface {
@@ -20,6 +22,22 @@ type Request struct {
 	ID      int             `json:"id"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params"`
+	// Result and Error are set when the client answers a request of the server
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+// IsResponse returns true if the message answers a request sent by the server
+func (r *Request) IsResponse() bool {
+	return r.Method == ""
+}
+
+// OutgoingRequest is a request sent by the server to the client
+type OutgoingRequest struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
 }
 
 type Response struct {
@@ -52,6 +70,11 @@ type Server struct {
 	Reader                io.Reader
 	Writer                io.Writer
 	notificationsProvider NotificationsProvider
+	// Requests sent to the client waiting for a response, indexed by id
+	pending   map[int]chan *Request
+	lastID    int
+	mutex     sync.Mutex
+	writeLock sync.Mutex
 }
 
 func NewServer(in io.Reader, out io.Writer) *Server {
@@ -64,6 +87,7 @@ func NewServer(in io.Reader, out io.Writer) *Server {
 		Reader:                in,
 		Writer:                out,
 		notificationsProvider: nil,
+		pending:               make(map[int]chan *Request),
 	}
 }
 
@@ -105,9 +129,8 @@ func (s *Server) GetResponse(r *Request) (bool, interface{}, *ResponseError) {
 			s.Logger.Printf("Method not found %s\n", r.Method)
 			return true, nil, NewError(MethodNotFound, "", nil)
 		}
-		// Call the notification handler
-		// Use a goroutine to resolve this request as fast as possible
-		go handler(r.Params)
+		// Call the notification handler, notifications are handled in order by Listen
+		handler(r.Params)
 		return false, nil, nil
 	}
 	// Call the request handler
@@ -148,7 +171,9 @@ func (s *Server) HandleError(err error) {
 }
 
 // Listen continuously reads the input for requests
-// It uses goroutines to handle requests as they come
+// It uses goroutines to handle requests as they come, notifications are handled one after the other
+// so that the changes of the documents are applied in order before the requests following them
+// Notification handlers must not wait for a response of the client
 func (s *Server) Listen() {
 	go s.SendNotifications()
 	for {
@@ -157,10 +182,61 @@ func (s *Server) Listen() {
 			go s.HandleResponse(&Resolution{Err: readErr, Reply: true, Res: nil})
 			return
 		}
+		if req.IsResponse() {
+			s.resolve(req)
+			continue
+		}
+		if _, ok := s.notificationHandlers[req.Method]; ok {
+			s.HandleRequest(req)
+			continue
+		}
 		go s.HandleRequest(req)
 	}
 }
 
+// SendRequest sends a request to the client and waits for its response until the context is done
+// The client is asked to cancel a request whose response is not awaited anymore
+func (s *Server) SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, *ResponseError) {
+	s.mutex.Lock()
+	s.lastID++
+	id := s.lastID
+	response := make(chan *Request, 1)
+	s.pending[id] = response
+	s.mutex.Unlock()
+	err := s.write(&OutgoingRequest{Jsonrpc: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		s.mutex.Lock()
+		delete(s.pending, id)
+		s.mutex.Unlock()
+		return nil, NewError(InternalError, err.Error(), nil)
+	}
+	select {
+	case res := <-response:
+		return res.Result, res.Error
+	case <-ctx.Done():
+		s.mutex.Lock()
+		delete(s.pending, id)
+		s.mutex.Unlock()
+		if err := s.PrintNotification(&Notification{Method: "$/cancelRequest", Params: map[string]int{"id": id}}); err != nil {
+			s.Logger.Println(err)
+		}
+		return nil, NewError(RequestCancelled, fmt.Sprintf("No response to %s: %s", method, ctx.Err()), nil)
+	}
+}
+
+// resolve passes a response of the client to the request waiting for it
+func (s *Server) resolve(res *Request) {
+	s.mutex.Lock()
+	response, ok := s.pending[res.ID]
+	delete(s.pending, res.ID)
+	s.mutex.Unlock()
+	if !ok {
+		s.Logger.Printf("Received a response to an unknown request %d\n", res.ID)
+		return
+	}
+	response <- res
+}
+
 func (s *Server) SendNotifications() {
 	if s.notificationsProvider == nil {
 		return
@@ -184,28 +260,24 @@ func (s *Server) PrintError(id int, err *ResponseError) error {
 func (s *Server) PrintResponse(id int, contents interface{}, resErr *ResponseError) error {
 	// Build the response object
 	res := &Response{ID: id, Error: resErr, Result: contents}
-	jsonString, err := json.Marshal(res)
-	if err != nil {
-		return err
-	}
-	s.Logger.Printf("Sending response: %s\n", jsonString)
-	_, err = fmt.Fprintf(s.Writer, "Content-Length: %d\r\n\r\n%s", len(jsonString), jsonString)
-	if err != nil {
-		return err
-	}
-	return nil
+	return s.write(res)
 }
 
 // PrintNotification sends a notification back to the client
 func (s *Server) PrintNotification(notification *Notification) error {
-	jsonString, err := json.Marshal(notification)
+	return s.write(notification)
+}
+
+// write sends a message to the client
+// Messages are written from several goroutines, the lock keeps them from interleaving
+func (s *Server) write(message interface{}) error {
+	jsonString, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
-	s.Logger.Printf("Sending notification: %s\n", jsonString)
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	s.Logger.Printf("Sending: %s\n", jsonString)
 	_, err = fmt.Fprintf(s.Writer, "Content-Length: %d\r\n\r\n%s", len(jsonString), jsonString)
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
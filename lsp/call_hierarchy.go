@@ -0,0 +1,32 @@
+package lsp
+
+import (
+	"encoding/json"
+	"taskfile-language-server/jsonrpc"
+)
+
+func (s *LSPServer) CallHierarchyIncomingCalls(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &CallHierarchyIncomingCallsParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(CallHierarchyIncomingCalls)
+	if !ok {
+		return nil, MethodNotFoundError("CallHierarchyIncomingCalls")
+	}
+	return i.CallHierarchyIncomingCalls(parsed)
+}
+
+func (s *LSPServer) CallHierarchyOutgoingCalls(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &CallHierarchyOutgoingCallsParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(CallHierarchyOutgoingCalls)
+	if !ok {
+		return nil, MethodNotFoundError("CallHierarchyOutgoingCalls")
+	}
+	return i.CallHierarchyOutgoingCalls(parsed)
+}
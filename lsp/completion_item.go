@@ -3,12 +3,10 @@ package lsp
 import (
 	"encoding/json"
 	"taskfile-language-server/jsonrpc"
-
-	"github.com/sourcegraph/go-lsp"
 )
 
 func (s *LSPServer) CompletionItemResolve(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
-	item := &lsp.CompletionItem{}
+	item := &CompletionItem{}
 	err := json.Unmarshal(params, item)
 	if err != nil {
 		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
@@ -3,6 +3,7 @@ package lsp
 import "taskfile-language-server/jsonrpc"
 
 const (
-	RequestCancelled jsonrpc.ErrorCode = -32800
+	RequestCancelled jsonrpc.ErrorCode = jsonrpc.RequestCancelled
 	ContentModified  jsonrpc.ErrorCode = -32801
+	RequestFailed    jsonrpc.ErrorCode = -32803
 )
@@ -3,12 +3,10 @@ package lsp
 import (
 	"encoding/json"
 	"taskfile-language-server/jsonrpc"
-
-	"github.com/sourcegraph/go-lsp"
 )
 
 func (s *LSPServer) InitializeHandler(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
-	parsed := &lsp.InitializeParams{}
+	parsed := &InitializeParams{}
 	err := json.Unmarshal(params, parsed)
 	if err != nil {
 		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
@@ -9,7 +9,7 @@ import (
 )
 
 type ServerImplementation interface {
-	Initialize(*lsp.InitializeParams) (*lsp.InitializeResult, *jsonrpc.ResponseError)
+	Initialize(*InitializeParams) (*InitializeResult, *jsonrpc.ResponseError)
 	Initialized() *jsonrpc.ResponseError
 }
 
@@ -28,7 +28,71 @@ type TextDocumentCompletion interface {
 }
 
 type CompletionItemResolve interface {
-	CompletionItemResolve(*lsp.CompletionItem) (*lsp.CompletionItem, *jsonrpc.ResponseError)
+	CompletionItemResolve(*CompletionItem) (*CompletionItem, *jsonrpc.ResponseError)
+}
+
+type TextDocumentFormatting interface {
+	TextDocumentFormatting(*lsp.DocumentFormattingParams) ([]lsp.TextEdit, *jsonrpc.ResponseError)
+}
+
+type TextDocumentRangeFormatting interface {
+	TextDocumentRangeFormatting(*lsp.DocumentRangeFormattingParams) ([]lsp.TextEdit, *jsonrpc.ResponseError)
+}
+
+type TextDocumentFoldingRange interface {
+	TextDocumentFoldingRange(*FoldingRangeParams) ([]FoldingRange, *jsonrpc.ResponseError)
+}
+
+type TextDocumentSemanticTokensFull interface {
+	TextDocumentSemanticTokensFull(*SemanticTokensParams) (*SemanticTokens, *jsonrpc.ResponseError)
+}
+
+type TextDocumentSemanticTokensRange interface {
+	TextDocumentSemanticTokensRange(*SemanticTokensRangeParams) (*SemanticTokens, *jsonrpc.ResponseError)
+}
+
+type TextDocumentCodeAction interface {
+	TextDocumentCodeAction(*lsp.CodeActionParams) ([]CodeAction, *jsonrpc.ResponseError)
+}
+
+type TextDocumentDocumentHighlight interface {
+	TextDocumentDocumentHighlight(*lsp.TextDocumentPositionParams) ([]lsp.DocumentHighlight, *jsonrpc.ResponseError)
+}
+
+type TextDocumentInlayHint interface {
+	TextDocumentInlayHint(*InlayHintParams) ([]InlayHint, *jsonrpc.ResponseError)
+}
+
+type TextDocumentPrepareCallHierarchy interface {
+	TextDocumentPrepareCallHierarchy(*lsp.TextDocumentPositionParams) ([]CallHierarchyItem, *jsonrpc.ResponseError)
+}
+
+type CallHierarchyIncomingCalls interface {
+	CallHierarchyIncomingCalls(*CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, *jsonrpc.ResponseError)
+}
+
+type CallHierarchyOutgoingCalls interface {
+	CallHierarchyOutgoingCalls(*CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, *jsonrpc.ResponseError)
+}
+
+type TextDocumentSelectionRange interface {
+	TextDocumentSelectionRange(*SelectionRangeParams) ([]SelectionRange, *jsonrpc.ResponseError)
+}
+
+type TextDocumentRename interface {
+	TextDocumentRename(*lsp.RenameParams) (*lsp.WorkspaceEdit, *jsonrpc.ResponseError)
+}
+
+type TextDocumentDocumentLink interface {
+	TextDocumentDocumentLink(*DocumentLinkParams) ([]DocumentLink, *jsonrpc.ResponseError)
+}
+
+type TextDocumentCodeLens interface {
+	TextDocumentCodeLens(*lsp.CodeLensParams) ([]lsp.CodeLens, *jsonrpc.ResponseError)
+}
+
+type WorkspaceExecuteCommand interface {
+	WorkspaceExecuteCommand(*lsp.ExecuteCommandParams) (interface{}, *jsonrpc.ResponseError)
 }
 
 type TextDocumentHover interface {
@@ -68,6 +132,22 @@ func NewServer(s *jsonrpc.Server, impl Implementation, logger *log.Logger) *LSPS
 	s.AddHandler("completionItem/resolve", server.CompletionItemResolve)
 	s.AddNotificationHandler("workspace/didChangeWatchedFiles", server.DidChangeWatchedFiles)
 	s.AddHandler("textDocument/hover", server.TextDocumentHover)
+	s.AddHandler("textDocument/formatting", server.TextDocumentFormatting)
+	s.AddHandler("textDocument/rangeFormatting", server.TextDocumentRangeFormatting)
+	s.AddHandler("textDocument/foldingRange", server.TextDocumentFoldingRange)
+	s.AddHandler("textDocument/semanticTokens/full", server.TextDocumentSemanticTokensFull)
+	s.AddHandler("textDocument/semanticTokens/range", server.TextDocumentSemanticTokensRange)
+	s.AddHandler("textDocument/codeLens", server.TextDocumentCodeLens)
+	s.AddHandler("textDocument/codeAction", server.TextDocumentCodeAction)
+	s.AddHandler("textDocument/documentLink", server.TextDocumentDocumentLink)
+	s.AddHandler("textDocument/documentHighlight", server.TextDocumentDocumentHighlight)
+	s.AddHandler("textDocument/inlayHint", server.TextDocumentInlayHint)
+	s.AddHandler("textDocument/prepareCallHierarchy", server.TextDocumentPrepareCallHierarchy)
+	s.AddHandler("callHierarchy/incomingCalls", server.CallHierarchyIncomingCalls)
+	s.AddHandler("callHierarchy/outgoingCalls", server.CallHierarchyOutgoingCalls)
+	s.AddHandler("textDocument/selectionRange", server.TextDocumentSelectionRange)
+	s.AddHandler("textDocument/rename", server.TextDocumentRename)
+	s.AddHandler("workspace/executeCommand", server.WorkspaceExecuteCommand)
 
 	s.SetNotificationsProvider(impl)
 
@@ -76,3 +76,172 @@ func (s *LSPServer) TextDocumentHover(params json.RawMessage) (interface{}, *jso
 	}
 	return i.TextDocumentHover(parsed)
 }
+
+func (s *LSPServer) TextDocumentFormatting(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.DocumentFormattingParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentFormatting)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentFormatting")
+	}
+	return i.TextDocumentFormatting(parsed)
+}
+
+func (s *LSPServer) TextDocumentRangeFormatting(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.DocumentRangeFormattingParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentRangeFormatting)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentRangeFormatting")
+	}
+	return i.TextDocumentRangeFormatting(parsed)
+}
+
+func (s *LSPServer) TextDocumentFoldingRange(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &FoldingRangeParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentFoldingRange)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentFoldingRange")
+	}
+	return i.TextDocumentFoldingRange(parsed)
+}
+
+func (s *LSPServer) TextDocumentSemanticTokensFull(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &SemanticTokensParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentSemanticTokensFull)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentSemanticTokensFull")
+	}
+	return i.TextDocumentSemanticTokensFull(parsed)
+}
+
+func (s *LSPServer) TextDocumentSemanticTokensRange(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &SemanticTokensRangeParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentSemanticTokensRange)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentSemanticTokensRange")
+	}
+	return i.TextDocumentSemanticTokensRange(parsed)
+}
+
+func (s *LSPServer) TextDocumentCodeLens(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.CodeLensParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentCodeLens)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentCodeLens")
+	}
+	return i.TextDocumentCodeLens(parsed)
+}
+
+func (s *LSPServer) TextDocumentCodeAction(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.CodeActionParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentCodeAction)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentCodeAction")
+	}
+	return i.TextDocumentCodeAction(parsed)
+}
+
+func (s *LSPServer) TextDocumentDocumentLink(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &DocumentLinkParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentDocumentLink)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentDocumentLink")
+	}
+	return i.TextDocumentDocumentLink(parsed)
+}
+
+func (s *LSPServer) TextDocumentDocumentHighlight(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.TextDocumentPositionParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentDocumentHighlight)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentDocumentHighlight")
+	}
+	return i.TextDocumentDocumentHighlight(parsed)
+}
+
+func (s *LSPServer) TextDocumentInlayHint(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &InlayHintParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentInlayHint)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentInlayHint")
+	}
+	return i.TextDocumentInlayHint(parsed)
+}
+
+func (s *LSPServer) TextDocumentPrepareCallHierarchy(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.TextDocumentPositionParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentPrepareCallHierarchy)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentPrepareCallHierarchy")
+	}
+	return i.TextDocumentPrepareCallHierarchy(parsed)
+}
+
+func (s *LSPServer) TextDocumentSelectionRange(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &SelectionRangeParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentSelectionRange)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentSelectionRange")
+	}
+	return i.TextDocumentSelectionRange(parsed)
+}
+
+func (s *LSPServer) TextDocumentRename(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.RenameParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(TextDocumentRename)
+	if !ok {
+		return nil, MethodNotFoundError("TextDocumentRename")
+	}
+	return i.TextDocumentRename(parsed)
+}
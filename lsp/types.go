@@ -35,3 +35,353 @@ type Hover struct {
 	 */
 	Range lsp.Range `json:"range,omitempty"`
 }
+
+type CompletionItem struct {
+	lsp.CompletionItem
+	/**
+	 * A human-readable string that represents a doc-comment.
+	 * Overrides the plain string documentation of the sourcegraph implementation
+	 */
+	Documentation *MarkupContent `json:"documentation,omitempty"`
+}
+
+type GeneralClientCapabilities struct {
+	/**
+	 * The position encodings supported by the client, in order of preference
+	 */
+	PositionEncodings []string `json:"positionEncodings,omitempty"`
+}
+
+type ClientCapabilities struct {
+	lsp.ClientCapabilities
+	/**
+	 * General client capabilities
+	 */
+	General GeneralClientCapabilities `json:"general,omitempty"`
+}
+
+type InitializeParams struct {
+	lsp.InitializeParams
+	/**
+	 * The capabilities provided by the client (editor or tool)
+	 */
+	Capabilities ClientCapabilities `json:"capabilities"`
+}
+
+type ServerCapabilities struct {
+	lsp.ServerCapabilities
+	/**
+	 * The position encoding the server picked from the encodings offered by the client
+	 */
+	PositionEncoding string `json:"positionEncoding,omitempty"`
+	/**
+	 * The server provides folding provider support
+	 */
+	FoldingRangeProvider bool `json:"foldingRangeProvider,omitempty"`
+	/**
+	 * The server provides semantic tokens support
+	 */
+	SemanticTokensProvider *SemanticTokensOptions `json:"semanticTokensProvider,omitempty"`
+	/**
+	 * The server provides document link support
+	 */
+	DocumentLinkProvider *DocumentLinkOptions `json:"documentLinkProvider,omitempty"`
+	/**
+	 * The server provides inlay hints
+	 */
+	InlayHintProvider bool `json:"inlayHintProvider,omitempty"`
+	/**
+	 * The server provides call hierarchy support
+	 */
+	CallHierarchyProvider bool `json:"callHierarchyProvider,omitempty"`
+	/**
+	 * The server provides selection range support
+	 */
+	SelectionRangeProvider bool `json:"selectionRangeProvider,omitempty"`
+}
+
+type InitializeResult struct {
+	/**
+	 * The capabilities the language server provides
+	 */
+	Capabilities ServerCapabilities `json:"capabilities"`
+}
+
+type FoldingRangeKind string
+
+const (
+	CommentFoldingRange FoldingRangeKind = "comment"
+	ImportsFoldingRange FoldingRangeKind = "imports"
+	RegionFoldingRange  FoldingRangeKind = "region"
+)
+
+type FoldingRangeParams struct {
+	/**
+	 * The text document
+	 */
+	TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+}
+
+type FoldingRange struct {
+	/**
+	 * The zero-based start line of the range to fold
+	 */
+	StartLine int `json:"startLine"`
+	/**
+	 * The zero-based end line of the range to fold
+	 */
+	EndLine int `json:"endLine"`
+	/**
+	 * Describes the kind of the folding range
+	 */
+	Kind FoldingRangeKind `json:"kind,omitempty"`
+}
+
+type SemanticTokensLegend struct {
+	/**
+	 * The token types a server uses
+	 */
+	TokenTypes []string `json:"tokenTypes"`
+	/**
+	 * The token modifiers a server uses
+	 */
+	TokenModifiers []string `json:"tokenModifiers"`
+}
+
+type SemanticTokensOptions struct {
+	/**
+	 * The legend used by the server
+	 */
+	Legend SemanticTokensLegend `json:"legend"`
+	/**
+	 * Server supports providing semantic tokens for a specific range of a document
+	 */
+	Range bool `json:"range,omitempty"`
+	/**
+	 * Server supports providing semantic tokens for a full document
+	 */
+	Full bool `json:"full,omitempty"`
+}
+
+type SemanticTokensParams struct {
+	/**
+	 * The text document
+	 */
+	TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SemanticTokensRangeParams struct {
+	/**
+	 * The text document
+	 */
+	TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+	/**
+	 * The range the semantic tokens are requested for
+	 */
+	Range lsp.Range `json:"range"`
+}
+
+type SemanticTokens struct {
+	/**
+	 * The encoded tokens: line delta, start delta, length, token type and token modifiers
+	 */
+	Data []int `json:"data"`
+}
+
+type CodeAction struct {
+	/**
+	 * A short, human-readable, title for this code action
+	 */
+	Title string `json:"title"`
+	/**
+	 * The kind of the code action, used to filter code actions
+	 */
+	Kind lsp.CodeActionKind `json:"kind,omitempty"`
+	/**
+	 * The diagnostics that this code action resolves
+	 */
+	Diagnostics []lsp.Diagnostic `json:"diagnostics,omitempty"`
+	/**
+	 * Marks this as a preferred action, applied by the `auto fix` command
+	 */
+	IsPreferred bool `json:"isPreferred,omitempty"`
+	/**
+	 * The workspace edit this code action performs
+	 */
+	Edit *lsp.WorkspaceEdit `json:"edit,omitempty"`
+	/**
+	 * A command this code action executes, after the edit if both are provided
+	 */
+	Command *lsp.Command `json:"command,omitempty"`
+}
+
+type ApplyWorkspaceEditParams struct {
+	/**
+	 * An optional label of the workspace edit, presented in the undo stack
+	 */
+	Label string `json:"label,omitempty"`
+	/**
+	 * The edits to apply
+	 */
+	Edit lsp.WorkspaceEdit `json:"edit"`
+}
+
+type ApplyWorkspaceEditResult struct {
+	/**
+	 * Indicates whether the edit was applied or not
+	 */
+	Applied bool `json:"applied"`
+	/**
+	 * An optional textual description for why the edit was not applied
+	 */
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+type DocumentLinkOptions struct {
+	/**
+	 * Document links have a resolve provider as well
+	 */
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+type DocumentLinkParams struct {
+	/**
+	 * The document to provide document links for
+	 */
+	TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentLink struct {
+	/**
+	 * The range this link applies to
+	 */
+	Range lsp.Range `json:"range"`
+	/**
+	 * The uri this link points to
+	 */
+	Target lsp.DocumentURI `json:"target,omitempty"`
+	/**
+	 * The tooltip text when you hover over this link
+	 */
+	Tooltip string `json:"tooltip,omitempty"`
+}
+
+type InlayHintKind int
+
+const (
+	TypeInlayHint      InlayHintKind = 1
+	ParameterInlayHint InlayHintKind = 2
+)
+
+type InlayHintParams struct {
+	/**
+	 * The text document
+	 */
+	TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+	/**
+	 * The visible document range for which inlay hints should be computed
+	 */
+	Range lsp.Range `json:"range"`
+}
+
+type InlayHint struct {
+	/**
+	 * The position of this hint
+	 */
+	Position lsp.Position `json:"position"`
+	/**
+	 * The label of this hint
+	 */
+	Label string `json:"label"`
+	/**
+	 * The kind of this hint, omitted for other hints
+	 */
+	Kind InlayHintKind `json:"kind,omitempty"`
+	/**
+	 * The tooltip text when you hover over this hint
+	 */
+	Tooltip string `json:"tooltip,omitempty"`
+	/**
+	 * Render padding before the hint
+	 */
+	PaddingLeft bool `json:"paddingLeft,omitempty"`
+}
+
+type CallHierarchyItem struct {
+	/**
+	 * The name of this item
+	 */
+	Name string `json:"name"`
+	/**
+	 * The kind of this item
+	 */
+	Kind lsp.SymbolKind `json:"kind"`
+	/**
+	 * More detail for this item, e.g. the signature of a function
+	 */
+	Detail string `json:"detail,omitempty"`
+	/**
+	 * The resource identifier of this item
+	 */
+	URI lsp.DocumentURI `json:"uri"`
+	/**
+	 * The range enclosing this symbol
+	 */
+	Range lsp.Range `json:"range"`
+	/**
+	 * The range that should be selected and revealed when this symbol is being picked
+	 */
+	SelectionRange lsp.Range `json:"selectionRange"`
+}
+
+type CallHierarchyIncomingCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+}
+
+type CallHierarchyIncomingCall struct {
+	/**
+	 * The item that makes the call
+	 */
+	From CallHierarchyItem `json:"from"`
+	/**
+	 * The ranges at which the calls appear, relative to the caller
+	 */
+	FromRanges []lsp.Range `json:"fromRanges"`
+}
+
+type CallHierarchyOutgoingCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+}
+
+type CallHierarchyOutgoingCall struct {
+	/**
+	 * The item that is called
+	 */
+	To CallHierarchyItem `json:"to"`
+	/**
+	 * The ranges at which this item is called, relative to the caller of the outgoing calls request
+	 */
+	FromRanges []lsp.Range `json:"fromRanges"`
+}
+
+type SelectionRangeParams struct {
+	/**
+	 * The text document
+	 */
+	TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+	/**
+	 * The positions inside the text document
+	 */
+	Positions []lsp.Position `json:"positions"`
+}
+
+type SelectionRange struct {
+	/**
+	 * The range of this selection range
+	 */
+	Range lsp.Range `json:"range"`
+	/**
+	 * The parent selection range containing this range
+	 */
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"taskfile-language-server/jsonrpc"
 
 	"github.com/sourcegraph/go-lsp"
 )
@@ -21,3 +22,16 @@ func (s *LSPServer) DidChangeWatchedFiles(params json.RawMessage) {
 	}
 	i.WorkspaceDidChangeWatchedFiles(parsed)
 }
+
+func (s *LSPServer) WorkspaceExecuteCommand(params json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+	parsed := &lsp.ExecuteCommandParams{}
+	err := json.Unmarshal(params, parsed)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ParseError, err.Error(), nil)
+	}
+	i, ok := s.impl.(WorkspaceExecuteCommand)
+	if !ok {
+		return nil, MethodNotFoundError("WorkspaceExecuteCommand")
+	}
+	return i.WorkspaceExecuteCommand(parsed)
+}
@@ -9,6 +9,7 @@ import (
 	"taskfile-language-server/extension"
 	"taskfile-language-server/jsonrpc"
 	"taskfile-language-server/lsp"
+	"taskfile-language-server/taskfile"
 )
 
 // These variables are provided at build time using ldflags
@@ -46,8 +47,8 @@ func main() {
 	reader := os.Stdin
 	writer := os.Stdout
 
-	// Create the taskfile implementation of the LSP
-	impl := extension.New()
+	// Create the taskfile implementation of the LSP, the Taskfiles are held by its store
+	impl := extension.New(taskfile.NewStore())
 	// Create the jsonrpc server
 	s := jsonrpc.NewServer(reader, writer)
 
@@ -0,0 +1,119 @@
+package taskfile
+
+import (
+	"sort"
+	"strings"
+)
+
+// Dependent is a task calling another task, possibly from another Taskfile
+type Dependent struct {
+	Taskfile *Taskfile
+	Task     *Task
+	// Calls of the task, in the order of the source
+	Calls []Call
+}
+
+// Known returns the Taskfiles of the store and the Taskfiles they include, sorted by path
+func (s *Store) Known() []*Taskfile {
+	paths := s.Paths()
+	queue := make([]*Taskfile, 0, len(paths))
+	for _, p := range paths {
+		if tf := s.Get(p); tf != nil {
+			queue = append(queue, tf)
+		}
+	}
+	known := make(map[string]*Taskfile)
+	for len(queue) > 0 {
+		tf := queue[0]
+		queue = queue[1:]
+		if _, ok := known[tf.Path]; ok {
+			continue
+		}
+		known[tf.Path] = tf
+		included, _ := tf.Included()
+		for _, it := range included {
+			queue = append(queue, it.Taskfile)
+		}
+	}
+	taskfiles := make([]*Taskfile, 0, len(known))
+	for _, tf := range known {
+		taskfiles = append(taskfiles, tf)
+	}
+	sort.Slice(taskfiles, func(i, j int) bool { return taskfiles[i].Path < taskfiles[j].Path })
+	return taskfiles
+}
+
+// Roots returns the Taskfiles including a Taskfile which are not included themselves, sorted by path
+// A Taskfile which is not included is its own root
+func (t *Taskfile) Roots() []*Taskfile {
+	store := t.loader()
+	roots := make([]*Taskfile, 0)
+	for _, p := range store.IncludersOf(t.Path) {
+		if len(store.IncludersOf(p)) > 0 {
+			continue
+		}
+		if tf := store.Get(p); tf != nil {
+			roots = append(roots, tf)
+		}
+	}
+	if len(roots) == 0 {
+		roots = append(roots, t)
+	}
+	return roots
+}
+
+// ResolveCall returns the task called by a name from the Taskfile
+// A name starting with a colon calls a task of the root Taskfile, the first root knowing it is used
+func (t *Taskfile) ResolveCall(name string) (*Taskfile, *Task) {
+	if !strings.HasPrefix(name, ":") {
+		return t.ResolveTask(name)
+	}
+	for _, root := range t.Roots() {
+		if target, task := root.ResolveTask(strings.TrimPrefix(name, ":")); task != nil {
+			return target, task
+		}
+	}
+	return nil, nil
+}
+
+// Calls returns true if a name called from the Taskfile resolves to a task
+// Taskfiles are parsed again when they change, their paths are compared
+func (t *Taskfile) Calls(name string, target *Taskfile, task *Task) bool {
+	tf, called := t.ResolveCall(name)
+	return called != nil && tf.Path == target.Path && called.Name == task.Name
+}
+
+// Dependents returns the tasks calling a task of the Taskfile, from the Taskfile, the Taskfiles known
+// by the store and their includes, sorted by path and name
+func (t *Taskfile) Dependents(name string) []Dependent {
+	dependents := make([]Dependent, 0)
+	task, ok := t.Tasks[name]
+	if !ok {
+		return dependents
+	}
+	taskfiles := []*Taskfile{t}
+	for _, tf := range t.loader().Known() {
+		if tf.Path != t.Path {
+			taskfiles = append(taskfiles, tf)
+		}
+	}
+	sort.Slice(taskfiles, func(i, j int) bool { return taskfiles[i].Path < taskfiles[j].Path })
+	for _, tf := range taskfiles {
+		for _, caller := range tf.sortedTasks() {
+			calls := make([]Call, 0)
+			for _, c := range caller.Calls() {
+				if tf.Calls(c.Task, t, task) {
+					calls = append(calls, c)
+				}
+			}
+			if len(calls) > 0 {
+				dependents = append(dependents, Dependent{Taskfile: tf, Task: caller, Calls: calls})
+			}
+		}
+	}
+	sort.SliceStable(dependents, func(i, j int) bool {
+		a, b := dependents[i], dependents[j]
+		return a.Taskfile.Path < b.Taskfile.Path || a.Taskfile.Path == b.Taskfile.Path && a.Task.Name < b.Task.Name
+	})
+	return dependents
+}
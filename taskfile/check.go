@@ -0,0 +1,405 @@
+package taskfile
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ProblemCode string
+
+const (
+	// The Taskfile does not declare its version
+	ProblemMissingVersion ProblemCode = "missing-version"
+	// A task calls a task that does not exist
+	ProblemUnknownTask ProblemCode = "unknown-task"
+	// An expression uses a variable that is not declared
+	ProblemUndefinedVar ProblemCode = "undefined-var"
+	// The cmds of a task are a string instead of a list
+	ProblemStringCmds ProblemCode = "string-cmds"
+	// The same sequence of commands is repeated in several places
+	ProblemDuplicateCmds ProblemCode = "duplicate-cmds"
+	// An included Taskfile does not exist and the include is not optional
+	ProblemMissingInclude ProblemCode = "missing-include"
+	// A Taskfile includes itself, directly or not
+	ProblemIncludeCycle ProblemCode = "include-cycle"
+	// An expression calls a function that is neither a function of Task nor of text/template
+	ProblemUnknownFunction ProblemCode = "unknown-function"
+	// A line is not valid YAML, it is ignored
+	ProblemSyntax ProblemCode = "syntax-error"
+	// A dotenv file does not exist where Task reads it
+	ProblemMissingDotenv ProblemCode = "missing-dotenv"
+)
+
+// Occurrence is a sequence of commands of a task, End is excluded
+type Occurrence struct {
+	Task  string
+	Start int
+	End   int
+}
+
+// Problem is a mistake found in a Taskfile
+type Problem struct {
+	Code    ProblemCode
+	Message string
+	Range   Range
+	// Task is the name of the task holding the problem, empty outside of the tasks
+	Task string
+	// Name is the name of the unknown task or of the undefined variable
+	Name string
+	// Suggestion is the name of the existing task closest to an unknown task
+	Suggestion string
+	// Occurrences are the places where duplicated commands are found
+	Occurrences []Occurrence
+}
+
+// Check looks for common mistakes in a Taskfile
+func (t *Taskfile) Check() []Problem {
+	problems := make([]Problem, 0)
+	for _, e := range t.SyntaxErrors {
+		problems = append(problems, Problem{
+			Code:    ProblemSyntax,
+			Message: e.Message,
+			Range:   t.Source.NewRange(e.Line, Indentation(t.Source.Line(e.Line)), e.Line, len(t.Source.Line(e.Line))),
+		})
+	}
+	if _, ok := t.Entries["version"]; !ok {
+		problems = append(problems, Problem{
+			Code:    ProblemMissingVersion,
+			Message: "The version of the Taskfile is missing",
+			Range:   t.Source.NewRange(0, 0, 0, len(t.Source.Line(0))),
+		})
+	}
+	// The names declared outside of the expressions are looked up once
+	includer := t.includerVars()
+	decl := declarations{includer: includer, dotenv: t.DotenvVars(nil)}
+	for _, e := range t.Expressions {
+		problems = append(problems, t.checkExpression(e, nil, decl)...)
+	}
+	problems = append(problems, t.checkDotenv(nil)...)
+	for _, task := range t.sortedTasks() {
+		decl := declarations{includer: includer, dotenv: t.DotenvVars(task), passed: t.PassedVars(task)}
+		for _, c := range task.Calls() {
+			if _, called := t.ResolveTask(c.Task); called != nil || !t.isCheckable(c.Task) {
+				continue
+			}
+			suggestion := ClosestTask(t, c.Task)
+			message := fmt.Sprintf("Task %s does not exist", c.Task)
+			if suggestion != "" {
+				message = fmt.Sprintf("%s, did you mean %s?", message, suggestion)
+			}
+			problems = append(problems, Problem{
+				Code:       ProblemUnknownTask,
+				Message:    message,
+				Range:      c.Range,
+				Task:       task.Name,
+				Name:       c.Task,
+				Suggestion: suggestion,
+			})
+		}
+		for _, e := range task.Expressions {
+			problems = append(problems, t.checkExpression(e, task, decl)...)
+		}
+		problems = append(problems, t.checkDotenv(task)...)
+		if task.CmdsString {
+			problems = append(problems, Problem{
+				Code:    ProblemStringCmds,
+				Message: "The cmds of a task must be a list",
+				Range:   task.Commands[0].Range,
+				Task:    task.Name,
+			})
+		}
+	}
+	_, includeProblems := t.Included()
+	problems = append(problems, includeProblems...)
+	return append(problems, t.checkDuplicateCmds()...)
+}
+
+// sortedTasks returns the tasks in the order of the document
+func (t *Taskfile) sortedTasks() []*Task {
+	tasks := make([]*Task, 0, len(t.Tasks))
+	for _, task := range t.Tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Range[0] < tasks[j].Range[0] })
+	return tasks
+}
+
+// isCheckable returns false for the names that can not be checked:
+// templates and tasks of namespaces that are not known, their includes may use templates
+func (t *Taskfile) isCheckable(name string) bool {
+	return name != "" && !strings.Contains(name, "{{") && (!strings.Contains(name, ":") || t.IsNamespaced(name))
+}
+
+// declarations are the names declared outside of a Taskfile and of its tasks for the expressions of a task
+type declarations struct {
+	// includer are the vars of the Taskfiles including the Taskfile and of their includes
+	includer map[string]bool
+	dotenv   map[string]*Var
+	// passed are the vars passed by the callers of the task
+	passed map[string]bool
+}
+
+// checkExpression reports the fields of an expression that are not declared and the unknown functions
+// Fields can be declared by the task, the Taskfile, the callers of the task, Task itself or the environment
+// The tokens are checked when the template can not be parsed
+func (t *Taskfile) checkExpression(e Expr, task *Task, decl declarations) []Problem {
+	problems := make([]Problem, 0)
+	taskName := ""
+	if task != nil {
+		taskName = task.Name
+	}
+	fields := make([]TemplateToken, 0)
+	if e.Nodes == nil {
+		fields = e.Tokens
+	}
+	for _, n := range e.Nodes {
+		switch n.Kind {
+		case NodeField:
+			fields = append(fields, TemplateToken{Kind: TokenField, Value: n.Value, Range: n.Range})
+		case NodeFunction:
+			if IsFunction(n.Value) {
+				continue
+			}
+			problems = append(problems, Problem{
+				Code:    ProblemUnknownFunction,
+				Message: fmt.Sprintf("Function %s is not defined", n.Value),
+				Range:   n.Range,
+				Task:    taskName,
+				Name:    n.Value,
+			})
+		}
+	}
+	for _, tk := range fields {
+		name := tk.FieldName()
+		if name == "" || t.isDeclared(name, task, decl) {
+			continue
+		}
+		problems = append(problems, Problem{
+			Code:    ProblemUndefinedVar,
+			Message: fmt.Sprintf("Variable %s is not defined", name),
+			Range:   tk.Range,
+			Task:    taskName,
+			Name:    name,
+		})
+	}
+	return problems
+}
+
+func (t *Taskfile) isDeclared(name string, task *Task, decl declarations) bool {
+	if _, ok := t.Vars[name]; ok {
+		return true
+	}
+	if _, ok := t.Env[name]; ok {
+		return true
+	}
+	if _, ok := os.LookupEnv(name); ok || SpecialVars[name] {
+		return true
+	}
+	if _, ok := decl.dotenv[name]; ok || decl.includer[name] {
+		return true
+	}
+	if task == nil {
+		return false
+	}
+	if _, ok := task.Vars[name]; ok {
+		return true
+	}
+	if _, ok := task.Env[name]; ok {
+		return true
+	}
+	return decl.passed[name]
+}
+
+// includerVars returns the variables declared by the Taskfiles including the Taskfile, in their vars or in the vars of their includes
+func (t *Taskfile) includerVars() map[string]bool {
+	vars := make(map[string]bool)
+	store := t.loader()
+	for _, p := range store.IncludersOf(t.Path) {
+		includer := store.Get(p)
+		if includer == nil {
+			continue
+		}
+		for name := range includer.Vars {
+			vars[name] = true
+		}
+		for _, include := range includer.Includes {
+			if included, ok := includer.IncludedTaskfile(include); !ok || included != t.Path {
+				continue
+			}
+			for name := range include.Vars {
+				vars[name] = true
+			}
+		}
+	}
+	return vars
+}
+
+// PassedVars returns the variables passed to a task by its callers, from any Taskfile known by the store
+func (t *Taskfile) PassedVars(task *Task) map[string]bool {
+	vars := make(map[string]bool)
+	for _, d := range t.Dependents(task.Name) {
+		for _, c := range d.Calls {
+			for name := range c.Vars {
+				vars[name] = true
+			}
+		}
+	}
+	return vars
+}
+
+// IsPassedVar returns true if a caller of a task passes a variable to the task
+func (t *Taskfile) IsPassedVar(task *Task, name string) bool {
+	return t.PassedVars(task)[name]
+}
+
+// ClosestTask returns the name of the task closest to a misspelled name
+// An empty string is returned if no task is close enough
+func ClosestTask(t *Taskfile, name string) string {
+	// Allow a swap of two letters in short names
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 1
+		if len(name) >= 4 {
+			maxDistance = 2
+		}
+	}
+	best := ""
+	bestDistance := maxDistance + 1
+	candidates := make([]string, 0, len(t.Tasks))
+	for other := range t.Tasks {
+		candidates = append(candidates, other)
+	}
+	included, _ := t.Included()
+	for _, it := range included {
+		candidates = append(candidates, it.Name)
+	}
+	for _, other := range candidates {
+		d := distance(name, other)
+		if d < bestDistance || (d == bestDistance && other < best) {
+			best = other
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between two strings
+func distance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// MaxDuplicateCmds is the length of the longest sequence of commands compared, longer sequences are reported in parts
+const MaxDuplicateCmds = 8
+
+// checkDuplicateCmds reports the sequences of at least two commands repeated in the tasks
+// The longest sequences are reported first, a command belongs to a single sequence
+func (t *Taskfile) checkDuplicateCmds() []Problem {
+	sequences := make(map[string][]Occurrence)
+	lengths := make(map[string]int)
+	keys := make([]string, 0)
+	// Commands are compared by the index of their value, a sequence is keyed by the indices of its commands
+	ids := make(map[string]string)
+	for _, task := range t.sortedTasks() {
+		if task.CmdsFlow || task.CmdsString {
+			continue
+		}
+		for i := range task.Commands {
+			key := ""
+			for j := i; j < len(task.Commands) && j < i+MaxDuplicateCmds; j++ {
+				value := task.Commands[j].Shell()
+				if value == "" {
+					break
+				}
+				id, ok := ids[value]
+				if !ok {
+					id = strconv.Itoa(len(ids))
+					ids[value] = id
+				}
+				key += id + ","
+				if j == i {
+					continue
+				}
+				if _, ok := sequences[key]; !ok {
+					keys = append(keys, key)
+					lengths[key] = j - i + 1
+				}
+				sequences[key] = append(sequences[key], Occurrence{Task: task.Name, Start: i, End: j + 1})
+			}
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return lengths[keys[i]] > lengths[keys[j]]
+	})
+	used := make(map[string]map[int]bool)
+	problems := make([]Problem, 0)
+	for _, key := range keys {
+		occurrences := make([]Occurrence, 0)
+		for _, o := range sequences[key] {
+			if used[o.Task] == nil {
+				used[o.Task] = make(map[int]bool)
+			}
+			free := true
+			for i := o.Start; i < o.End; i++ {
+				free = free && !used[o.Task][i]
+			}
+			if !free {
+				continue
+			}
+			occurrences = append(occurrences, o)
+			for i := o.Start; i < o.End; i++ {
+				used[o.Task][i] = true
+			}
+		}
+		if len(occurrences) < 2 {
+			// Release the commands for the shorter sequences
+			for _, o := range occurrences {
+				for i := o.Start; i < o.End; i++ {
+					used[o.Task][i] = false
+				}
+			}
+			continue
+		}
+		for _, o := range occurrences {
+			cmds := t.Tasks[o.Task].Commands
+			first, last := cmds[o.Start].Range, cmds[o.End-1].Range
+			problems = append(problems, Problem{
+				Code:        ProblemDuplicateCmds,
+				Message:     fmt.Sprintf("These %d commands are repeated %d times", o.End-o.Start, len(occurrences)),
+				Range:       Range{first[0], first[1], last[2], last[3]},
+				Task:        o.Task,
+				Occurrences: occurrences,
+			})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,262 @@
+package taskfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dotenv is a parsed dotenv file
+type Dotenv struct {
+	Path string
+	Vars map[string]*Var
+	// Keys in the order of the file
+	Keys    []string
+	modTime time.Time
+	size    int64
+}
+
+// dotenvEscapes are the escape sequences of the double quoted values
+var dotenvEscapes = map[byte]string{'n': "\n", 'r': "\r", 't': "\t", '"': "\"", '\\': "\\", '$': "$"}
+
+// ParseDotenv parses the content of a dotenv file
+// Lines are `KEY=value`, optionally prefixed with `export`. Values can be single quoted, taken literally,
+// or double quoted, with escape sequences and line breaks. `${KEY}` and `$KEY` are expanded in the values that
+// are not single quoted, from the keys declared before. Comments start with `#`, invalid lines are ignored
+// The ranges of the variables use the given encoding
+func ParseDotenv(path string, contents string, enc PositionEncoding) *Dotenv {
+	src := NewSource(contents, enc)
+	env := &Dotenv{Path: path, Vars: make(map[string]*Var), Keys: make([]string, 0)}
+	for l := 0; l < src.LineCount(); l++ {
+		line := src.Line(l)
+		start := len(line) - len(strings.TrimLeft(line, " \t"))
+		rest := line[start:]
+		if strings.HasPrefix(rest, "export ") || strings.HasPrefix(rest, "export\t") {
+			rest = strings.TrimLeft(rest[len("export"):], " \t")
+			start = len(line) - len(rest)
+		}
+		eq := strings.Index(rest, "=")
+		if rest == "" || rest[0] == '#' || eq <= 0 {
+			continue
+		}
+		name := strings.TrimRight(rest[:eq], " \t")
+		if !isDotenvKey(name) {
+			continue
+		}
+		value, endLine, endCol := parseDotenvValue(src, l, start+eq+1, env.Vars)
+		v := &Var{
+			Name:      name,
+			Value:     value,
+			File:      path,
+			NameRange: src.NewRange(l, start, l, start+len(name)),
+			Range:     src.NewRange(l, start, endLine, endCol),
+		}
+		if _, ok := env.Vars[name]; !ok {
+			env.Keys = append(env.Keys, name)
+		}
+		env.Vars[name] = v
+		l = endLine
+	}
+	return env
+}
+
+func isDotenvKey(name string) bool {
+	for i, r := range name {
+		if !(r == '_' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// parseDotenvValue parses the value starting at a byte column, returns the value and where it ends
+func parseDotenvValue(src *Source, l int, col int, vars map[string]*Var) (string, int, int) {
+	line := src.Line(l)
+	rest := strings.TrimLeft(line[col:], " \t")
+	col = len(line) - len(rest)
+	switch {
+	case strings.HasPrefix(rest, "'"):
+		if end := strings.Index(rest[1:], "'"); end >= 0 {
+			return rest[1 : end+1], l, col + end + 2
+		}
+	case strings.HasPrefix(rest, `"`):
+		// Double quoted values can span several lines
+		var b strings.Builder
+		text, i := rest, 1
+		for {
+			for i < len(text) {
+				c := text[i]
+				if c == '"' {
+					end := len(src.Line(l)) - len(text) + i + 1
+					return expandDotenv(b.String(), vars), l, end
+				}
+				if c == '\\' && i+1 < len(text) {
+					if esc, ok := dotenvEscapes[text[i+1]]; ok {
+						b.WriteString(esc)
+						i += 2
+						continue
+					}
+				}
+				b.WriteByte(c)
+				i++
+			}
+			if l+1 >= src.LineCount() {
+				break
+			}
+			b.WriteByte('\n')
+			l++
+			text, i = src.Line(l), 0
+		}
+		// The quote is not closed, the value is the rest of the line
+		l = l - strings.Count(b.String(), "\n")
+		line = src.Line(l)
+	}
+	value := rest
+	// Comments of unquoted values are preceded by a space
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	value = strings.TrimRight(value, " \t")
+	return expandDotenv(value, vars), l, col + len(value)
+}
+
+// expandDotenv replaces `${KEY}` and `$KEY` by the values of the keys declared before
+func expandDotenv(value string, vars map[string]*Var) string {
+	return os.Expand(value, func(name string) string {
+		if v, ok := vars[name]; ok {
+			return v.Value
+		}
+		return "$" + name
+	})
+}
+
+// LoadDotenv returns a dotenv file, read again if it changed since it was last read
+// Returns false if the file can not be read
+func (s *Store) LoadDotenv(path string) (*Dotenv, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		s.InvalidateDotenv(path)
+		return nil, false
+	}
+	s.mutex.RLock()
+	env, ok := s.dotenvs[path]
+	s.mutex.RUnlock()
+	if ok && env.modTime.Equal(info.ModTime()) && env.size == info.Size() {
+		return env, true
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	env = ParseDotenv(path, string(contents), s.Encoding())
+	env.modTime, env.size = info.ModTime(), info.Size()
+	s.mutex.Lock()
+	s.dotenvs[path] = env
+	s.mutex.Unlock()
+	return env, true
+}
+
+// checkDotenv reports the dotenv files of a task, or of the Taskfile when the task is nil, which do not exist
+// Task skips them
+func (t *Taskfile) checkDotenv(task *Task) []Problem {
+	problems := make([]Problem, 0)
+	files := t.Dotenv
+	taskName := ""
+	if task != nil {
+		files, taskName = task.Dotenv, task.Name
+	}
+	for _, f := range files {
+		if strings.Contains(f.Value, "{{") {
+			continue
+		}
+		p := t.DotenvPath(task, f.Value)
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			continue
+		}
+		problems = append(problems, Problem{
+			Code:    ProblemMissingDotenv,
+			Message: fmt.Sprintf("The dotenv file %s does not exist, Task skips it", p),
+			Range:   f.Range,
+			Task:    taskName,
+			Name:    f.Value,
+		})
+	}
+	return problems
+}
+
+// InvalidateDotenv forgets a dotenv file, returns false if it was not loaded
+func (s *Store) InvalidateDotenv(path string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.dotenvs[path]
+	delete(s.dotenvs, path)
+	return ok
+}
+
+// DotenvUsers returns the paths of the parsed Taskfiles referencing a dotenv file, sorted
+func (s *Store) DotenvUsers(p string) []string {
+	users := make([]string, 0)
+	for path, tf := range s.taskfiles() {
+		files := tf.dotenvFiles(nil)
+		for _, task := range tf.Tasks {
+			files = append(files, tf.dotenvFiles(task)...)
+		}
+		if indexOf(files, p) >= 0 {
+			users = append(users, path)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// DotenvPath returns the path of a dotenv file of a task, nil for a file of the Taskfile
+// The files of a task are relative to its `dir`, or to the Taskfile when its dir uses templates
+func (t *Taskfile) DotenvPath(task *Task, file string) string {
+	dir := filepath.Dir(t.Path)
+	if task != nil && task.Dir != nil && !strings.Contains(task.Dir.Value, "{{") {
+		dir = ResolvePath(dir, task.Dir.Value)
+	}
+	return ResolvePath(dir, file)
+}
+
+// dotenvFiles returns the paths of the dotenv files of a task and of the Taskfile, the files of the task first
+// The files using templates are left out
+func (t *Taskfile) dotenvFiles(task *Task) []string {
+	files := make([]string, 0)
+	if task != nil {
+		for _, f := range task.Dotenv {
+			if !strings.Contains(f.Value, "{{") {
+				files = append(files, t.DotenvPath(task, f.Value))
+			}
+		}
+	}
+	for _, f := range t.Dotenv {
+		if !strings.Contains(f.Value, "{{") {
+			files = append(files, t.DotenvPath(nil, f.Value))
+		}
+	}
+	return files
+}
+
+// DotenvVars returns the variables of the dotenv files of a task and of the Taskfile, nil for the Taskfile alone
+// The first file declaring a variable wins, the files of the task come first
+func (t *Taskfile) DotenvVars(task *Task) map[string]*Var {
+	vars := make(map[string]*Var)
+	store := t.loader()
+	for _, f := range t.dotenvFiles(task) {
+		env, ok := store.LoadDotenv(f)
+		if !ok {
+			continue
+		}
+		for name, v := range env.Vars {
+			if _, ok := vars[name]; !ok {
+				vars[name] = v
+			}
+		}
+	}
+	return vars
+}
@@ -0,0 +1,131 @@
+package taskfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string]string
+	}{
+		{"plain", "A=1\nB = two words\n", map[string]string{"A": "1", "B": "two words"}},
+		{"export", "export A=1\nexport\tB=2\nexported=3\n", map[string]string{"A": "1", "B": "2", "exported": "3"}},
+		{"comments", "# A=0\nA=1 # one\nB=2#two\n", map[string]string{"A": "1", "B": "2#two"}},
+		{"single quotes", `A='$B \n # x'` + "\n", map[string]string{"A": `$B \n # x`}},
+		{"double quotes", `A="a\tb\n\"c\" \\ \$d"` + "\n", map[string]string{"A": "a\tb\n\"c\" \\ $d"}},
+		{"multiline", "A=\"one\ntwo\"\nB=3\n", map[string]string{"A": "one\ntwo", "B": "3"}},
+		{"unclosed quote", "A=\"one\nB=2\n", map[string]string{"A": "\"one", "B": "2"}},
+		{"expansion", "A=1\nB=${A}-$A\nC='${A}'\nD=\"$A $UNSET\"\n", map[string]string{"A": "1", "B": "1-1", "C": "${A}", "D": "1 $UNSET"}},
+		{"invalid lines", "=1\n1A=2\nA\nA.B=3\n", map[string]string{"A.B": "3"}},
+		{"redeclared", "A=1\nA=2\n", map[string]string{"A": "2"}},
+		{"crlf", "A=1\r\nB='2'\r\n", map[string]string{"A": "1", "B": "2"}},
+	}
+	for _, tt := range tests {
+		env := ParseDotenv("/.env", tt.contents, UTF16)
+		got := make(map[string]string)
+		for name, v := range env.Vars {
+			got[name] = v.Value
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseDotenv() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseDotenvRanges(t *testing.T) {
+	env := ParseDotenv("/.env", "export A=1\n  B=\"x\ny\"\n", UTF16)
+	tests := []struct {
+		name      string
+		nameRange Range
+		rng       Range
+	}{
+		{"A", Range{0, 7, 0, 8}, Range{0, 7, 0, 10}},
+		{"B", Range{1, 2, 1, 3}, Range{1, 2, 2, 2}},
+	}
+	for _, tt := range tests {
+		v := env.Vars[tt.name]
+		if v == nil {
+			t.Errorf("%s is missing", tt.name)
+			continue
+		}
+		if !reflect.DeepEqual(v.NameRange, tt.nameRange) || !reflect.DeepEqual(v.Range, tt.rng) {
+			t.Errorf("%s: ranges = %v, %v, want %v, %v", tt.name, v.NameRange, v.Range, tt.nameRange, tt.rng)
+		}
+	}
+	if want := []string{"A", "B"}; !reflect.DeepEqual(env.Keys, want) {
+		t.Errorf("keys = %v, want %v", env.Keys, want)
+	}
+}
+
+func TestDotenvVars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotenv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		".env":          "A=root\nB=root\n",
+		"sub/.env":      "A=sub\n",
+		"sub/.env.task": "C=task\n",
+	}
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := NewStore()
+	path := filepath.Join(dir, "Taskfile.yml")
+	store.Open(path, 1, `version: '3'
+dotenv: [.env]
+tasks:
+  sub:
+    dir: sub
+    dotenv: [.env, .env.task, missing.env]
+  templated:
+    dir: '{{.DIR}}'
+    dotenv: [sub/.env]
+`)
+	tf := store.Get(path)
+	tests := []struct {
+		task string
+		want map[string]string
+	}{
+		{"", map[string]string{"A": "root", "B": "root"}},
+		// The files of a task are relative to its dir and come first
+		{"sub", map[string]string{"A": "sub", "B": "root", "C": "task"}},
+		// A dir using templates falls back to the directory of the Taskfile
+		{"templated", map[string]string{"A": "sub", "B": "root"}},
+	}
+	for _, tt := range tests {
+		got := make(map[string]string)
+		for name, v := range tf.DotenvVars(tf.Tasks[tt.task]) {
+			got[name] = v.Value
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: DotenvVars() = %v, want %v", tt.task, got, tt.want)
+		}
+	}
+	missing := make([]string, 0)
+	for _, p := range tf.Check() {
+		if p.Code == ProblemMissingDotenv {
+			missing = append(missing, p.Message)
+		}
+	}
+	want := []string{"The dotenv file " + filepath.Join(dir, "sub", "missing.env") + " does not exist, Task skips it"}
+	if !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing dotenv files = %v, want %v", missing, want)
+	}
+	if users := store.DotenvUsers(filepath.Join(dir, "sub", ".env.task")); !reflect.DeepEqual(users, []string{path}) {
+		t.Errorf("DotenvUsers() = %v", users)
+	}
+}
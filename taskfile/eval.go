@@ -0,0 +1,206 @@
+package taskfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+
+	sprig "github.com/go-task/slim-sprig/v3"
+)
+
+// taskFuncs are the functions Task adds to slim-sprig, the ones depending on the time, the machine, the running task
+// or the libraries of Task are nil, the templates calling them have an unknown value
+var taskFuncs = template.FuncMap{
+	"OS":         func() string { return runtime.GOOS },
+	"ARCH":       func() string { return runtime.GOARCH },
+	"numCPU":     func() int { return runtime.NumCPU() },
+	"catLines":   func(s string) string { return strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s) },
+	"splitLines": func(s string) []string { return strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n") },
+	"fromSlash":  filepath.FromSlash,
+	"toSlash":    filepath.ToSlash,
+	"exeExt":     exeExt,
+	"joinPath":   func(elem ...string) string { return filepath.Join(elem...) },
+	"relPath":    filepath.Rel,
+	"merge":      merge,
+	"IsSH":       func() bool { return true },
+	// Deprecated names of Task
+	"FromSlash": filepath.FromSlash,
+	"ToSlash":   filepath.ToSlash,
+	"ExeExt":    exeExt,
+	// Quoted and split like the shell interpreter of Task does
+	"shellQuote": nil,
+	"q":          nil,
+	"splitArgs":  nil,
+	// Encoded by the libraries of Task
+	"spew":         nil,
+	"fromYaml":     nil,
+	"mustFromYaml": nil,
+	"toYaml":       nil,
+	"mustToYaml":   nil,
+	"uuid":         nil,
+	"randIntN":     nil,
+}
+
+// unknownSprigFuncs are the functions of slim-sprig depending on the time or the machine
+var unknownSprigFuncs = []string{"now", "ago", "randInt", "getHostByName"}
+
+// TaskFuncs are the names of the functions of the templates of Task, its own functions and the ones of slim-sprig
+var TaskFuncs = map[string]bool{}
+
+// TemplateFuncs are the functions of Task run by the server to render the templates
+// The other functions of TaskFuncs depend on the time, the machine or the running task, the templates calling them have an unknown value
+var TemplateFuncs = template.FuncMap{}
+
+func init() {
+	for name, f := range sprig.TxtFuncMap() {
+		TaskFuncs[name] = true
+		TemplateFuncs[name] = f
+	}
+	for _, name := range unknownSprigFuncs {
+		delete(TemplateFuncs, name)
+	}
+	for name, f := range taskFuncs {
+		TaskFuncs[name] = true
+		if f != nil {
+			TemplateFuncs[name] = f
+		}
+	}
+}
+
+func exeExt() string {
+	if runtime.GOOS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
+
+// merge returns the entries of maps, the later ones override the earlier ones
+func merge(base map[string]interface{}, maps ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// TemplateError is an error of a template, while parsing or executing it
+type TemplateError struct {
+	Message string
+	// Line and byte column of the error in the template, from 0
+	Line int
+	Col  int
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line+1, e.Col+1, e.Message)
+}
+
+// templateErrorPattern matches the errors of text/template, the column is only given when executing
+var templateErrorPattern = regexp.MustCompile(`^template: [^:]*:(\d+)(?::(\d+))?: (?:executing "[^"]*" at <[^>]*>: )?(.*)$`)
+
+func newTemplateError(err error) *TemplateError {
+	m := templateErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return &TemplateError{Message: err.Error()}
+	}
+	line, _ := strconv.Atoi(m[1])
+	col, _ := strconv.Atoi(m[2])
+	return &TemplateError{Message: m[3], Line: line - 1, Col: col}
+}
+
+// Eval renders a template with the functions of Task
+// Missing variables are rendered as empty strings like Task does
+func Eval(text string, data map[string]interface{}) (string, *TemplateError) {
+	tpl, err := template.New("").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return "", newTemplateError(err)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", newTemplateError(err)
+	}
+	return strings.Replace(b.String(), "<no value>", "", -1), nil
+}
+
+// renderWith renders a template, the variables are resolved by a lookup function
+// The value is unknown when the template uses a variable or calls a function known only when running Task
+func renderWith(s string, lookup func(name string) (Value, bool)) (Value, *TemplateError) {
+	data := make(map[string]interface{})
+	for _, a := range FindActions(s) {
+		for _, tk := range a.Tokens {
+			name := tk.FieldName()
+			if name == "" {
+				continue
+			}
+			value, ok := lookup(name)
+			if !ok {
+				continue
+			}
+			if value.Unknown {
+				return value, nil
+			}
+			data[name] = value.Text
+		}
+	}
+	if nodes, err := ParseTemplate(s); err == nil {
+		for _, n := range nodes {
+			if _, ok := TemplateFuncs[n.Value]; n.Kind != NodeFunction || ok || BuiltinFuncs[n.Value] {
+				continue
+			}
+			if !TaskFuncs[n.Value] {
+				return unknownValue("%s is not a function of Task", n.Value), nil
+			}
+			return unknownValue("%s is computed by Task when running", n.Value), nil
+		}
+	}
+	text, err := Eval(s, data)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{Text: text}, nil
+}
+
+// Render renders a string of a task with the variables resolved for the task, nil for the strings outside of the tasks
+func (t *Taskfile) Render(task *Task, s string) (Value, *TemplateError) {
+	vars := t.TaskVars(task)
+	return renderWith(s, func(name string) (Value, bool) {
+		if v, ok := vars[name]; ok {
+			return v.Value, true
+		}
+		return Value{}, false
+	})
+}
+
+// RenderedCommand is a shell command of a task with its templates rendered
+type RenderedCommand struct {
+	Range Range `json:"range"`
+	// Command is the command as written in the Taskfile
+	Command string `json:"command"`
+	Value   Value  `json:"value"`
+	// Error is set when the templates of the command can not be rendered
+	Error *TemplateError `json:"error,omitempty"`
+}
+
+// RenderCommands renders the shell commands of a task, deferred commands included
+func (t *Taskfile) RenderCommands(task *Task) []RenderedCommand {
+	rendered := make([]RenderedCommand, 0)
+	for _, c := range task.Commands {
+		for cmd := &c; cmd != nil; cmd = cmd.Defer {
+			if cmd.Cmd == nil {
+				continue
+			}
+			value, err := t.Render(task, cmd.Cmd.Value)
+			rendered = append(rendered, RenderedCommand{Range: cmd.Cmd.Range, Command: cmd.Cmd.Value, Value: value, Error: err})
+		}
+	}
+	return rendered
+}
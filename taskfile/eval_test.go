@@ -0,0 +1,85 @@
+package taskfile
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	data := map[string]interface{}{"S": "a b", "N": "3", "U": uint(0), "F": 0.5, "Z": 0.0}
+	tests := []struct {
+		text string
+		want string
+	}{
+		// Task
+		{"{{OS}}/{{ARCH}}", runtime.GOOS + "/" + runtime.GOARCH},
+		{`{{catLines "a\nb\r\nc"}}`, "a b c"},
+		{`{{splitLines "a\r\nb" | join ","}}`, "a,b"},
+		{`{{joinPath "a" "b"}}`, filepath.Join("a", "b")},
+		{`{{toSlash (fromSlash "a/b")}}`, "a/b"},
+		{`{{relPath "/a" "/a/b/c"}}`, filepath.Join("b", "c")},
+		{`{{(merge (dict "a" 1 "b" 2) (dict "b" 3)).b}}`, "3"},
+		{"{{IsSH}}", "true"},
+		// slim-sprig
+		{"{{.S | upper}}", "A B"},
+		{`{{trimPrefix "a" .S}}`, " b"},
+		{`{{.S | replace " " "-"}}`, "a-b"},
+		{`{{splitList " " .S | join ","}}`, "a,b"},
+		{"{{add .N 1}}", "4"},
+		{"{{mul .N 2 | sub 10}}", "4"},
+		{"{{div 7 2}}/{{mod 7 2}}", "3/1"},
+		{`{{max 1 3 2}}`, "3"},
+		{`{{default "d" ""}}`, "d"},
+		{`{{default "d" .S}}`, "a b"},
+		{`{{default "d" .U}}`, "d"},
+		{`{{default "d" .Z}}`, "d"},
+		{"{{empty .U}}/{{empty .F}}/{{empty .Z}}", "true/false/true"},
+		{`{{coalesce "" .U "c"}}`, "c"},
+		{`{{ternary "y" "n" true}}`, "y"},
+		{`{{quote "a" "b"}}`, `"a" "b"`},
+		{`{{list 1 2 | toJson}}`, "[1,2]"},
+		{`{{base "a/b.go"}} {{ext "a/b.go"}}`, "b.go .go"},
+		{`{{regexReplaceAll "[0-9]" "a1b2" "_"}}`, "a_b_"},
+		{`{{b64enc "task"}}`, "dGFzaw=="},
+		{`{{sha256sum "" | trunc 8}}`, "e3b0c442"},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.text, data)
+		if err != nil {
+			t.Errorf("Eval(%s) failed: %s", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%s) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRenderWithUnknownFunctions(t *testing.T) {
+	lookup := func(name string) (Value, bool) { return Value{}, false }
+	tests := []struct {
+		text    string
+		unknown bool
+	}{
+		{"{{now}}", true},
+		{"{{randInt 1 3}}", true},
+		{`{{shellQuote "a b"}}`, true},
+		{`{{splitArgs "a b"}}`, true},
+		{`{{toYaml (list 1)}}`, true},
+		{"{{uuid}}", true},
+		{"{{missing}}", true},
+		{`{{"2006" | toDate "2006" | date "06"}}`, false},
+		{"{{numCPU | toString | len | lt 0}}", false},
+	}
+	for _, tt := range tests {
+		value, err := renderWith(tt.text, lookup)
+		if err != nil {
+			t.Errorf("renderWith(%s) failed: %s", tt.text, err)
+			continue
+		}
+		if value.Unknown != tt.unknown {
+			t.Errorf("renderWith(%s) = %+v, want unknown %v", tt.text, value, tt.unknown)
+		}
+	}
+}
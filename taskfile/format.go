@@ -0,0 +1,626 @@
+package taskfile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/token"
+)
+
+// TopLevelKeys is the canonical order of the top level keys of a Taskfile
+// Unknown keys are kept in their original order right before the tasks
+var TopLevelKeys = []string{
+	"version", "output", "method", "includes", "set", "shopt",
+	"vars", "env", "dotenv", "run", "interval", "silent", "tasks",
+}
+
+type FormatOptions struct {
+	// Number of spaces used for each level of indentation
+	TabSize int
+	// Sort the tasks by name
+	SortTasks bool
+}
+
+// ErrFormat is returned when a Taskfile can't be formatted without changing its meaning
+type ErrFormat struct {
+	Line    int
+	Message string
+}
+
+func (e *ErrFormat) Error() string {
+	return fmt.Sprintf("Could not format line %d: %s", e.Line+1, e.Message)
+}
+
+type formatter struct {
+	src  *Source
+	opts FormatOptions
+	out  []string
+	// Full line comments indexed by the line of the node they precede
+	leading map[int][]int
+	// Comments following a value on the same line, indexed by line
+	trailing map[int]string
+	// Comments at the top of the document, separated from the first node by an empty line
+	header []int
+	// Comments following the last node of the document
+	footer []int
+}
+
+// Format rewrites a Taskfile with a normalised indentation, key order and quoting
+// Comments and line endings are preserved. The formatted document is parsed again and compared to the
+// original, an error is returned if the formatting would change its content
+func Format(contents string, opts FormatOptions) (string, error) {
+	if opts.TabSize <= 0 {
+		opts.TabSize = 2
+	}
+	// goccy fails on some documents with CRLF line endings, they are restored once formatted
+	original := contents
+	crlf := strings.Contains(contents, "\r\n")
+	contents = strings.ReplaceAll(contents, "\r\n", "\n")
+	f, err := parseWithoutComments(contents)
+	if err != nil {
+		return "", err
+	}
+	if len(f.Docs) != 1 || f.Docs[0].Body == nil {
+		return original, nil
+	}
+	body := f.Docs[0].Body
+	err = checkFormattable(body)
+	if err != nil {
+		return "", err
+	}
+	fm := &formatter{
+		src:      NewSource(contents, UTF8),
+		opts:     opts,
+		out:      make([]string, 0),
+		leading:  make(map[int][]int),
+		trailing: make(map[int]string),
+	}
+	fm.collectComments(body)
+	entries, ok := mappingEntries(body)
+	if !ok {
+		return "", &ErrFormat{Line: firstLine(body), Message: "the root of a Taskfile must be a mapping"}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return topLevelRank(entries[i]) < topLevelRank(entries[j])
+	})
+	for _, l := range fm.header {
+		fm.blankBefore(l)
+		fm.write(strings.TrimSpace(fm.src.Line(l)))
+	}
+	if len(fm.header) > 0 {
+		fm.write("")
+	}
+	for i, e := range entries {
+		// Top level blocks are separated by an empty line
+		if i > 0 && fm.out[len(fm.out)-1] != "" && (isBlock(e.Value) || isBlock(entries[i-1].Value)) {
+			fm.write("")
+		}
+		fm.entry(e, "", 0)
+	}
+	for _, l := range fm.footer {
+		fm.blankBefore(l)
+		fm.write(strings.TrimSpace(fm.src.Line(l)))
+	}
+	formatted := strings.Join(fm.out, "\n") + "\n"
+	err = verifyFormat(contents, body, formatted)
+	if err != nil {
+		return "", err
+	}
+	if crlf {
+		formatted = strings.ReplaceAll(formatted, "\n", "\r\n")
+	}
+	return formatted, nil
+}
+
+// isBlock returns true if a value is written on multiple lines
+func isBlock(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		return !n.IsFlowStyle
+	case *ast.SequenceNode:
+		return !n.IsFlowStyle
+	case *ast.MappingValueNode, *ast.LiteralNode:
+		return true
+	}
+	return false
+}
+
+func topLevelRank(node *ast.MappingValueNode) int {
+	key := ScalarValue(node.Key)
+	tasks := 0
+	for i, k := range TopLevelKeys {
+		if k == key {
+			return i * 2
+		}
+		if k == "tasks" {
+			tasks = i * 2
+		}
+	}
+	return tasks - 1
+}
+
+// mappingEntries returns the entries of a block or flow mapping
+func mappingEntries(node ast.Node) ([]*ast.MappingValueNode, bool) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		entries := make([]*ast.MappingValueNode, len(n.Values))
+		copy(entries, n.Values)
+		return entries, true
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}, true
+	}
+	return nil, false
+}
+
+// firstLine returns the 0-based line where a node starts
+func firstLine(node ast.Node) int {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if !n.IsFlowStyle && len(n.Values) > 0 {
+			return firstLine(n.Values[0])
+		}
+	case *ast.MappingValueNode:
+		return firstLine(n.Key)
+	case *ast.SequenceNode:
+		if !n.IsFlowStyle && len(n.Values) > 0 {
+			return firstLine(n.Values[0])
+		}
+	}
+	return node.GetToken().Position.Line - 1
+}
+
+// checkFormattable rejects the documents goccy does not parse faithfully
+func checkFormattable(node ast.Node) error {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, v := range n.Values {
+			err := checkFormattable(v)
+			if err != nil {
+				return err
+			}
+		}
+	case *ast.MappingValueNode:
+		// An empty value followed by a sibling key is parsed as a nested mapping
+		switch v := n.Value.(type) {
+		case *ast.MappingNode, *ast.MappingValueNode:
+			if m, ok := v.(*ast.MappingNode); !ok || !m.IsFlowStyle {
+				child, _ := mappingEntries(v)
+				if len(child) > 0 && child[0].Key.GetToken().Position.Column <= n.Key.GetToken().Position.Column {
+					return &ErrFormat{Line: firstLine(n), Message: "empty values are not supported"}
+				}
+			}
+		}
+		return checkFormattable(n.Value)
+	case *ast.SequenceNode:
+		for _, v := range n.Values {
+			err := checkFormattable(v)
+			if err != nil {
+				return err
+			}
+		}
+	case *ast.AnchorNode:
+		return checkFormattable(n.Value)
+	case *ast.TagNode:
+		return checkFormattable(n.Value)
+	}
+	return nil
+}
+
+// collectComments indexes the comments of the document by the node they belong to
+func (f *formatter) collectComments(body ast.Node) {
+	starts := make([]int, 0)
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.MappingNode:
+			for _, v := range n.Values {
+				walk(v)
+			}
+		case *ast.MappingValueNode:
+			starts = append(starts, firstLine(n))
+			walk(n.Value)
+		case *ast.SequenceNode:
+			for _, v := range n.Values {
+				starts = append(starts, firstLine(v))
+				walk(v)
+			}
+		case *ast.AnchorNode:
+			walk(n.Value)
+		case *ast.TagNode:
+			walk(n.Value)
+		}
+	}
+	walk(body)
+	sort.Ints(starts)
+	for _, tk := range lexer.Tokenize(f.src.Text) {
+		if tk.Type != token.CommentType {
+			continue
+		}
+		line, col := f.src.TokenStart(tk)
+		if strings.TrimSpace(f.src.Line(line)[:col]) != "" {
+			f.trailing[line] = strings.TrimSpace(f.src.Line(line)[col:])
+			continue
+		}
+		i := sort.SearchInts(starts, line+1)
+		if i == len(starts) {
+			f.footer = append(f.footer, line)
+			continue
+		}
+		if i == 0 && f.separated(line, starts[0]) {
+			f.header = append(f.header, line)
+			continue
+		}
+		f.leading[starts[i]] = append(f.leading[starts[i]], line)
+	}
+}
+
+// separated returns true if an empty line exists between two lines
+func (f *formatter) separated(from int, to int) bool {
+	for l := from + 1; l < to; l++ {
+		if strings.TrimSpace(f.src.Line(l)) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *formatter) indent(col int) string {
+	return strings.Repeat(" ", col)
+}
+
+// write adds a line to the output
+func (f *formatter) write(line string) {
+	f.out = append(f.out, strings.TrimRight(line, " "))
+}
+
+// writeLine adds a line to the output, followed by the trailing comment of a source line
+func (f *formatter) writeLine(line string, srcLine int) {
+	if c, ok := f.trailing[srcLine]; ok {
+		delete(f.trailing, srcLine)
+		line = fmt.Sprintf("%s %s", strings.TrimRight(line, " "), c)
+	}
+	f.write(line)
+}
+
+// blankBefore keeps a single empty line before a source line if there was one
+func (f *formatter) blankBefore(line int) {
+	if len(f.out) == 0 || f.out[len(f.out)-1] == "" {
+		return
+	}
+	if line > 0 && strings.TrimSpace(f.src.Line(line-1)) == "" {
+		f.write("")
+	}
+}
+
+// comments writes the comments preceding a node
+func (f *formatter) comments(line int, col int) {
+	for _, l := range f.leading[line] {
+		f.blankBefore(l)
+		f.write(f.indent(col) + strings.TrimSpace(f.src.Line(l)))
+	}
+	delete(f.leading, line)
+}
+
+// entry writes a mapping entry, lead is the text preceding the key on its line
+func (f *formatter) entry(node *ast.MappingValueNode, lead string, col int) {
+	line := firstLine(node)
+	if strings.TrimSpace(lead) == "" {
+		f.comments(line, col)
+		f.blankBefore(line)
+		lead = f.indent(col)
+	}
+	key := f.scalar(node.Key, false)
+	if ScalarValue(node.Key) == "tasks" && f.opts.SortTasks && col == 0 {
+		if tasks, ok := mappingEntries(node.Value); ok {
+			sort.SliceStable(tasks, func(i, j int) bool {
+				return ScalarValue(tasks[i].Key) < ScalarValue(tasks[j].Key)
+			})
+			f.writeLine(fmt.Sprintf("%s%s:", lead, key), line)
+			for _, t := range tasks {
+				f.entry(t, "", col+f.opts.TabSize)
+			}
+			return
+		}
+	}
+	f.value(node.Value, fmt.Sprintf("%s%s:", lead, key), line, col)
+}
+
+// value writes a value following a header (a key or a sequence entry)
+// col is the column of the owner of the value
+func (f *formatter) value(node ast.Node, header string, line int, col int) {
+	prefix := ""
+	for {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			prefix += fmt.Sprintf("&%s ", ScalarValue(n.Name))
+			node = n.Value
+			continue
+		case *ast.TagNode:
+			prefix += fmt.Sprintf("%s ", n.Start.Value)
+			node = n.Value
+			continue
+		}
+		break
+	}
+	switch n := node.(type) {
+	case nil:
+		f.writeLine(header+" "+prefix, line)
+	case *ast.LiteralNode:
+		f.literal(n, fmt.Sprintf("%s %s", header, prefix), line, col)
+	case *ast.MappingNode:
+		if n.IsFlowStyle {
+			f.writeLine(fmt.Sprintf("%s %s%s", header, prefix, f.flow(n)), line)
+			return
+		}
+		f.writeLine(fmt.Sprintf("%s %s", header, prefix), line)
+		for _, e := range n.Values {
+			f.entry(e, "", col+f.opts.TabSize)
+		}
+	case *ast.MappingValueNode:
+		f.writeLine(fmt.Sprintf("%s %s", header, prefix), line)
+		f.entry(n, "", col+f.opts.TabSize)
+	case *ast.SequenceNode:
+		if n.IsFlowStyle {
+			f.writeLine(fmt.Sprintf("%s %s%s", header, prefix, f.flow(n)), line)
+			return
+		}
+		f.writeLine(fmt.Sprintf("%s %s", header, prefix), line)
+		f.sequence(n, col+f.opts.TabSize)
+	default:
+		f.writeLine(fmt.Sprintf("%s %s%s", header, prefix, f.scalar(n, false)), line)
+	}
+}
+
+// sequence writes the entries of a block sequence
+func (f *formatter) sequence(node *ast.SequenceNode, col int) {
+	for _, v := range node.Values {
+		line := firstLine(v)
+		f.comments(line, col)
+		f.blankBefore(line)
+		f.item(v, f.indent(col)+"- ", col)
+	}
+}
+
+// item writes an entry of a block sequence, lead holds the dashes of the entry
+func (f *formatter) item(node ast.Node, lead string, col int) {
+	line := firstLine(node)
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if !n.IsFlowStyle {
+			for i, e := range n.Values {
+				if i == 0 {
+					f.entry(e, lead, len(lead))
+				} else {
+					f.entry(e, "", len(lead))
+				}
+			}
+			return
+		}
+	case *ast.MappingValueNode:
+		f.entry(n, lead, len(lead))
+		return
+	case *ast.SequenceNode:
+		if !n.IsFlowStyle {
+			for i, v := range n.Values {
+				if i == 0 {
+					f.item(v, lead+"- ", len(lead))
+				} else {
+					f.comments(firstLine(v), len(lead))
+					f.item(v, f.indent(len(lead))+"- ", len(lead))
+				}
+			}
+			return
+		}
+	}
+	f.value(node, strings.TrimRight(lead, " "), line, col)
+}
+
+var blockIndicator = regexp.MustCompile(`^([|>])([0-9]?)([+-]?)([0-9]?)$`)
+
+// literal writes a block scalar with its content indented under its owner
+func (f *formatter) literal(node *ast.LiteralNode, header string, line int, col int) {
+	start := node.Start.Position.Line - 1
+	parts := blockIndicator.FindStringSubmatch(node.Start.Value)
+	if parts == nil {
+		f.writeLine(header+node.Start.Value, line)
+		return
+	}
+	indicator := parts[1] + parts[3]
+	explicit := parts[2] + parts[4]
+	if explicit != "" {
+		indicator = fmt.Sprintf("%s%d%s", parts[1], f.opts.TabSize, parts[3])
+	}
+	f.writeLine(header+indicator, start)
+	// Find the lines of the block, they are more indented than the first content line
+	lines := make([]string, 0)
+	contentIndent := -1
+	if explicit != "" {
+		ownerIndent := len(f.src.Line(start)) - len(strings.TrimLeft(f.src.Line(start), " "))
+		n, _ := strconv.Atoi(explicit)
+		contentIndent = ownerIndent + n
+	}
+	for l := start + 1; l < f.src.LineCount(); l++ {
+		text := f.src.Line(l)
+		if strings.TrimSpace(text) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		if contentIndent < 0 {
+			contentIndent = indent
+		}
+		if indent < contentIndent || contentIndent == 0 {
+			break
+		}
+		lines = append(lines, text[contentIndent:])
+	}
+	// Trailing empty lines are only part of the value when kept
+	if parts[3] != "+" {
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+	}
+	for _, l := range lines {
+		if l == "" {
+			f.out = append(f.out, "")
+			continue
+		}
+		f.write(f.indent(col+f.opts.TabSize) + l)
+	}
+}
+
+// flow writes a flow mapping or sequence on a single line
+func (f *formatter) flow(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		items := make([]string, 0)
+		for _, e := range n.Values {
+			items = append(items, fmt.Sprintf("%s: %s", f.scalar(e.Key, true), f.flow(e.Value)))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(items, ", "))
+	case *ast.MappingValueNode:
+		return fmt.Sprintf("{%s: %s}", f.scalar(n.Key, true), f.flow(n.Value))
+	case *ast.SequenceNode:
+		items := make([]string, 0)
+		for _, v := range n.Values {
+			items = append(items, f.flow(v))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+	case *ast.AnchorNode:
+		return fmt.Sprintf("&%s %s", ScalarValue(n.Name), f.flow(n.Value))
+	case *ast.TagNode:
+		return fmt.Sprintf("%s %s", n.Start.Value, f.flow(n.Value))
+	}
+	return f.scalar(node, true)
+}
+
+var (
+	reservedPlain = regexp.MustCompile(`^(?i:true|false|yes|no|on|off|y|n|null|~)$`)
+	numberPlain   = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9][0-9_]*(\.[0-9_]*)?)([eE][-+]?[0-9]+)?$|^0[xob][0-9a-fA-F_]+$|^[-+]?\.(?i:inf)$|^\.(?i:nan)$`)
+)
+
+// isPlainSafe returns true if a string keeps its value when written without quotes
+func isPlainSafe(s string, inFlow bool) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+	if reservedPlain.MatchString(s) || numberPlain.MatchString(s) {
+		return false
+	}
+	switch s[0] {
+	case ',', '[', ']', '{', '}', '#', '&', '*', '!', '|', '>', '\'', '"', '%', '@', '`':
+		return false
+	case '-', '?', ':':
+		if len(s) == 1 || s[1] == ' ' {
+			return false
+		}
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	if inFlow && strings.ContainsAny(s, ",[]{}") {
+		return false
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// scalar formats a scalar value, plain if possible, single quoted otherwise
+func (f *formatter) scalar(node ast.Node, inFlow bool) string {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		if n.Token.Type == token.DoubleQuoteType {
+			// Escapes are not decoded by the parser, keep the original text
+			line, col := f.src.TokenStart(n.Token)
+			_, end := f.src.TokenEnd(n.Token)
+			text := f.src.Line(line)[col:end]
+			if strings.Contains(text, "\\") {
+				return text
+			}
+		}
+		if isPlainSafe(n.Value, inFlow) {
+			return n.Value
+		}
+		if strings.ContainsAny(n.Value, "\n\t\r") {
+			return strconv.Quote(n.Value)
+		}
+		return fmt.Sprintf("'%s'", strings.ReplaceAll(n.Value, "'", "''"))
+	case *ast.AliasNode:
+		return fmt.Sprintf("*%s", ScalarValue(n.Value))
+	case *ast.MergeKeyNode:
+		return n.Token.Value
+	case *ast.LiteralNode:
+		return strconv.Quote(n.Value.Value)
+	case nil:
+		return ""
+	}
+	return node.GetToken().Value
+}
+
+// canonical returns a representation of the content of a node
+// Mapping entries are sorted as the formatter may reorder them
+func canonical(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.MappingNode, *ast.MappingValueNode:
+		entries, _ := mappingEntries(n)
+		items := make([]string, 0)
+		for _, e := range entries {
+			items = append(items, fmt.Sprintf("%s: %s", canonical(e.Key), canonical(e.Value)))
+		}
+		sort.Strings(items)
+		return fmt.Sprintf("{%s}", strings.Join(items, ", "))
+	case *ast.SequenceNode:
+		items := make([]string, 0)
+		for _, v := range n.Values {
+			items = append(items, canonical(v))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+	case *ast.AnchorNode:
+		return fmt.Sprintf("&%s %s", ScalarValue(n.Name), canonical(n.Value))
+	case *ast.TagNode:
+		return fmt.Sprintf("%s %s", n.Start.Value, canonical(n.Value))
+	case *ast.AliasNode:
+		return fmt.Sprintf("*%s", ScalarValue(n.Value))
+	case ast.ScalarNode:
+		return strconv.Quote(fmt.Sprintf("%v", n.GetValue()))
+	case nil:
+		return "null"
+	}
+	return node.String()
+}
+
+// commentsOf returns the sorted comments of a document
+func commentsOf(contents string) []string {
+	comments := make([]string, 0)
+	for _, tk := range lexer.Tokenize(contents) {
+		if tk.Type == token.CommentType {
+			comments = append(comments, strings.TrimSpace(tk.Value))
+		}
+	}
+	sort.Strings(comments)
+	return comments
+}
+
+// verifyFormat makes sure the formatted document holds the same values and comments
+func verifyFormat(original string, body ast.Node, formatted string) error {
+	f, err := parseWithoutComments(formatted)
+	if err != nil || len(f.Docs) != 1 {
+		return &ErrFormat{Message: "the formatted document is not valid"}
+	}
+	if canonical(body) != canonical(f.Docs[0].Body) {
+		return &ErrFormat{Message: "formatting would change the content of the document"}
+	}
+	if strings.Join(commentsOf(original), "\n") != strings.Join(commentsOf(formatted), "\n") {
+		return &ErrFormat{Message: "formatting would lose comments"}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package taskfile
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"key order and indentation",
+			"tasks:\n  a:\n    cmds:\n    - echo\nversion: 3\n",
+			"version: 3\n\ntasks:\n  a:\n    cmds:\n      - echo\n",
+		},
+		{
+			"comments",
+			"# head\n\nversion: '3'\n# lead\ntasks:\n    a:   # trailing\n        cmd: echo  \"x\"\n# foot\n",
+			"# head\n\nversion: '3'\n\n# lead\ntasks:\n  a: # trailing\n    cmd: echo  \"x\"\n# foot\n",
+		},
+		{
+			"crlf",
+			"version: '3'\r\ntasks:\r\n  build:\r\n    cmds:\r\n    - echo hi # say\r\n",
+			"version: '3'\r\n\r\ntasks:\r\n  build:\r\n    cmds:\r\n      - echo hi # say\r\n",
+		},
+		{
+			"quoting",
+			"version: '3'\nvars:\n  A: \"plain\"\n  B: 'yes'\n  C: \"a\\tb\"\n",
+			"version: '3'\n\nvars:\n  A: plain\n  B: 'yes'\n  C: \"a\\tb\"\n",
+		},
+		{
+			"block scalar",
+			"version: '3'\ntasks:\n  a:\n    cmds:\n    - |\n        echo one\n        echo two\n",
+			"version: '3'\n\ntasks:\n  a:\n    cmds:\n      - |\n        echo one\n        echo two\n",
+		},
+		{
+			"missing final newline",
+			"version: '3'",
+			"version: '3'\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := Format(tt.in, FormatOptions{})
+		if err != nil {
+			t.Errorf("%s: Format() returned %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Format() = %q, want %q", tt.name, got, tt.want)
+		}
+		// Formatting a formatted document changes nothing
+		again, err := Format(got, FormatOptions{})
+		if err != nil || again != got {
+			t.Errorf("%s: Format() is not idempotent: %q, %v", tt.name, again, err)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		line int
+	}{
+		{"empty value", "version: '3'\nvars:\n  A:\n  B: x\n", 2},
+		{"root sequence", "- a\n- b\n", 0},
+	}
+	for _, tt := range tests {
+		_, err := Format(tt.in, FormatOptions{})
+		fe, ok := err.(*ErrFormat)
+		if !ok {
+			t.Errorf("%s: Format() returned %v, want an ErrFormat", tt.name, err)
+			continue
+		}
+		if fe.Line != tt.line {
+			t.Errorf("%s: Format() failed on line %d, want %d", tt.name, fe.Line, tt.line)
+		}
+	}
+}
+
+func TestVerifyFormat(t *testing.T) {
+	original := "version: '3'\n# comment\nvars:\n  A: one\n"
+	tests := []struct {
+		name      string
+		formatted string
+		ok        bool
+	}{
+		{"same content", "vars:\n  A: one\n# comment\nversion: '3'\n", true},
+		{"changed value", "version: '3'\n# comment\nvars:\n  A: two\n", false},
+		{"lost comment", "version: '3'\nvars:\n  A: one\n", false},
+		{"invalid document", "version: '3'\nvars: [\n", false},
+	}
+	f, err := parseWithoutComments(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		err := verifyFormat(original, f.Docs[0].Body, tt.formatted)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: verifyFormat() returned %v", tt.name, err)
+		}
+	}
+}
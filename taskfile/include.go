@@ -0,0 +1,303 @@
+package taskfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// Include is an entry of `includes`, the tasks of the included Taskfile are called with the namespace as prefix
+type Include struct {
+	Namespace string `json:"namespace"`
+	// Range of the namespace
+	Range Range `json:"range"`
+	// Taskfile is the path of the included Taskfile or of its directory, relative to the including Taskfile
+	Taskfile String `json:"taskfile"`
+	// Dir is the directory the included tasks run in
+	Dir      *String         `json:"dir,omitempty"`
+	Optional *Bool           `json:"optional,omitempty"`
+	Internal *Bool           `json:"internal,omitempty"`
+	Flatten  *Bool           `json:"flatten,omitempty"`
+	Aliases  []String        `json:"aliases,omitempty"`
+	Excludes []String        `json:"excludes,omitempty"`
+	Vars     map[string]*Var `json:"vars,omitempty"`
+}
+
+// ExtractIncludes returns the includes indexed by namespace
+// Includes whose path uses templates can not be resolved and are ignored
+func ExtractIncludes(node ast.Node, src *Source) map[string]*Include {
+	includes := make(map[string]*Include)
+	entries, _ := mappingEntries(node)
+	for _, entry := range entries {
+		include := &Include{Namespace: ScalarValue(entry.Key), Range: src.TokenRange(entry.Key.GetToken())}
+		if s := newString(entry.Value, src); s != nil {
+			include.Taskfile = *s
+		}
+		props, _ := mappingEntries(entry.Value)
+		for _, prop := range props {
+			switch ScalarValue(prop.Key) {
+			case "taskfile":
+				if s := newString(prop.Value, src); s != nil {
+					include.Taskfile = *s
+				}
+			case "dir":
+				include.Dir = newString(prop.Value, src)
+			case "optional":
+				include.Optional = newBool(prop.Value, src)
+			case "internal":
+				include.Internal = newBool(prop.Value, src)
+			case "flatten":
+				include.Flatten = newBool(prop.Value, src)
+			case "aliases":
+				include.Aliases = newStrings(prop.Value, src)
+			case "excludes":
+				include.Excludes = newStrings(prop.Value, src)
+			case "vars":
+				include.Vars = newVars(prop.Value, src)
+			}
+		}
+		if include.Taskfile.Value == "" || strings.Contains(include.Taskfile.Value, "{{") {
+			continue
+		}
+		includes[include.Namespace] = include
+	}
+	return includes
+}
+
+// ResolvePath returns the absolute path of a path relative to a directory
+func ResolvePath(dir string, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(dir, filepath.FromSlash(p))
+}
+
+// FindTaskfile returns the Taskfile of a directory, or the path itself if it is a file
+func FindTaskfile(p string) (string, bool) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", false
+	}
+	if !info.IsDir() {
+		return p, true
+	}
+	for _, name := range TaskfileNames {
+		candidate := filepath.Join(p, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// IncludedTask is a task of an included Taskfile, as seen from the including Taskfile
+type IncludedTask struct {
+	// Name is the name the task is called with, prefixed with the namespaces of the includes
+	Name string
+	// Aliases are the other names of the task, from the aliases of the includes and of the task
+	Aliases []string
+	Task    *Task
+	// Taskfile is the Taskfile defining the task
+	Taskfile *Taskfile
+	// Internal tasks can be called from the Taskfiles but not from the command line
+	Internal bool
+	// Dir is the directory the task runs in
+	Dir string
+	// Includes are the includes leading to the task, starting from the including Taskfile
+	Includes []*Include
+}
+
+// includeResolver collects the tasks of the Taskfiles included by a Taskfile
+type includeResolver struct {
+	store    *Store
+	tasks    []IncludedTask
+	problems []Problem
+}
+
+// includeScope holds what an include passes down to the Taskfiles it includes
+type includeScope struct {
+	chain    []string
+	prefixes []string
+	internal bool
+	dir      string
+	includes []*Include
+}
+
+// joinNames prefixes names with namespaces, an empty namespace leaves the name as is
+func joinNames(prefixes []string, names []string) []string {
+	joined := make([]string, 0, len(prefixes)*len(names))
+	for _, prefix := range prefixes {
+		for _, name := range names {
+			if prefix == "" {
+				joined = append(joined, name)
+			} else {
+				joined = append(joined, prefix+":"+name)
+			}
+		}
+	}
+	return joined
+}
+
+// IncludedTaskfile returns the Taskfile of an include, false if it does not exist
+func (t *Taskfile) IncludedTaskfile(include *Include) (string, bool) {
+	return FindTaskfile(ResolvePath(filepath.Dir(t.Path), include.Taskfile.Value))
+}
+
+// Included returns the tasks of the included Taskfiles, and the problems of the includes
+// The result is kept until a document of the store changes
+func (t *Taskfile) Included() ([]IncludedTask, []Problem) {
+	t.includedMutex.Lock()
+	defer t.includedMutex.Unlock()
+	store := t.loader()
+	// The generation is read first, a change while resolving the includes resolves them again next time
+	generation := store.currentGeneration()
+	if t.included == nil || t.includedGeneration != generation {
+		r := &includeResolver{store: store, tasks: make([]IncludedTask, 0), problems: make([]Problem, 0)}
+		r.include(t, includeScope{chain: []string{t.Path}, prefixes: []string{""}, dir: filepath.Dir(t.Path)})
+		t.included, t.includeProblems, t.includedGeneration = r.tasks, r.problems, generation
+	}
+	return t.included, t.includeProblems
+}
+
+// loader returns the Store loading the includes and the dotenv files of the Taskfile
+// A Taskfile parsed outside of a store reads them from disk every time
+func (t *Taskfile) loader() *Store {
+	if t.store == nil {
+		return NewStore()
+	}
+	return t.store
+}
+
+func (r *includeResolver) include(from *Taskfile, scope includeScope) {
+	namespaces := make([]string, 0, len(from.Includes))
+	for ns := range from.Includes {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		include := from.Includes[ns]
+		// Problems are reported on the include of this Taskfile leading to them
+		reported := include
+		if len(scope.includes) > 0 {
+			reported = scope.includes[0]
+		}
+		p, ok := from.IncludedTaskfile(include)
+		if !ok {
+			if include.Optional == nil || !include.Optional.Value {
+				r.problems = append(r.problems, Problem{
+					Code:    ProblemMissingInclude,
+					Message: fmt.Sprintf("The Taskfile %s included as %s does not exist", include.Taskfile.Value, ns),
+					Range:   reported.Taskfile.Range,
+					Name:    ns,
+				})
+			}
+			continue
+		}
+		if cycle := indexOf(scope.chain, p); cycle >= 0 {
+			r.problems = append(r.problems, Problem{
+				Code:    ProblemIncludeCycle,
+				Message: fmt.Sprintf("The include %s includes %s again", reported.Namespace, filepath.Base(p)),
+				Range:   reported.Range,
+				Name:    reported.Namespace,
+			})
+			continue
+		}
+		r.store.addIncluder(p, from.Path)
+		included := r.store.Get(p)
+		if included == nil {
+			continue
+		}
+		child := includeScope{
+			chain:    append(append([]string{}, scope.chain...), p),
+			prefixes: scope.prefixes,
+			internal: scope.internal || (include.Internal != nil && include.Internal.Value),
+			dir:      scope.dir,
+			includes: append(append([]*Include{}, scope.includes...), include),
+		}
+		if include.Flatten == nil || !include.Flatten.Value {
+			names := []string{ns}
+			for _, alias := range include.Aliases {
+				names = append(names, alias.Value)
+			}
+			child.prefixes = joinNames(scope.prefixes, names)
+		}
+		if include.Dir != nil {
+			child.dir = ResolvePath(filepath.Dir(from.Path), include.Dir.Value)
+		}
+		excluded := make(map[string]bool)
+		for _, e := range include.Excludes {
+			excluded[e.Value] = true
+		}
+		for _, task := range included.sortedTasks() {
+			if excluded[task.Name] {
+				continue
+			}
+			names := []string{task.Name}
+			for _, alias := range task.Aliases {
+				names = append(names, alias.Value)
+			}
+			names = joinNames(child.prefixes, names)
+			r.tasks = append(r.tasks, IncludedTask{
+				Name:     names[0],
+				Aliases:  names[1:],
+				Task:     task,
+				Taskfile: included,
+				Internal: child.internal || (task.Internal != nil && task.Internal.Value),
+				Dir:      child.dir,
+				Includes: child.includes,
+			})
+		}
+		r.include(included, child)
+	}
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// ResolveTask returns the task called by a name and the Taskfile defining it, nil if it does not exist
+// The tasks of the Taskfile come first, then the tasks of the included Taskfiles
+func (t *Taskfile) ResolveTask(name string) (*Taskfile, *Task) {
+	if task, ok := t.Tasks[name]; ok {
+		return t, task
+	}
+	for _, task := range t.Tasks {
+		for _, alias := range task.Aliases {
+			if alias.Value == name {
+				return t, task
+			}
+		}
+	}
+	included, _ := t.Included()
+	for _, it := range included {
+		if it.Name == name || indexOf(it.Aliases, name) >= 0 {
+			return it.Taskfile, it.Task
+		}
+	}
+	return nil, nil
+}
+
+// IsNamespaced returns true if a name starts with the namespace of an include found on disk
+func (t *Taskfile) IsNamespaced(name string) bool {
+	for ns, include := range t.Includes {
+		names := []string{ns}
+		for _, alias := range include.Aliases {
+			names = append(names, alias.Value)
+		}
+		for _, n := range names {
+			if _, ok := t.IncludedTaskfile(include); ok && strings.HasPrefix(name, n+":") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,411 @@
+package taskfile
+
+import (
+	"sort"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// String is a scalar of the Taskfile, its range excludes the quotes
+type String struct {
+	Value string `json:"value"`
+	Range Range  `json:"range"`
+}
+
+// String returns the value of a string, empty if it is not set
+func (s *String) String() string {
+	if s == nil {
+		return ""
+	}
+	return s.Value
+}
+
+// Bool is a boolean of the Taskfile
+type Bool struct {
+	Value bool  `json:"value"`
+	Range Range `json:"range"`
+}
+
+// Command is an item of the cmds of a task, either a shell command or a call of a task
+type Command struct {
+	Range Range `json:"range"`
+	// Cmd is the shell command of a string item or of a `cmd:` entry
+	Cmd *String `json:"cmd,omitempty"`
+	// Task is the task called by a `task:` entry
+	Task *String         `json:"task,omitempty"`
+	Vars map[string]*Var `json:"vars,omitempty"`
+	// Defer is the command run when the task exits, from a `defer:` entry
+	Defer       *Command `json:"defer,omitempty"`
+	For         *For     `json:"for,omitempty"`
+	Silent      *Bool    `json:"silent,omitempty"`
+	IgnoreError *Bool    `json:"ignoreError,omitempty"`
+	Platforms   []String `json:"platforms,omitempty"`
+}
+
+// Shell returns the shell command of a command without any other property, empty for the other commands
+func (c *Command) Shell() string {
+	if c.Cmd == nil || c.Task != nil || c.Defer != nil || c.For != nil || c.Silent != nil || c.IgnoreError != nil || len(c.Platforms) > 0 {
+		return ""
+	}
+	return c.Cmd.Value
+}
+
+// For is the loop of a command, over a list, the sources or generated files of the task, or a variable
+type For struct {
+	Range Range    `json:"range"`
+	Items []String `json:"items,omitempty"`
+	// Files is either `sources` or `generates`
+	Files *String `json:"files,omitempty"`
+	Var   *String `json:"var,omitempty"`
+	Split *String `json:"split,omitempty"`
+	As    *String `json:"as,omitempty"`
+}
+
+// Dep is an item of the deps of a task
+type Dep struct {
+	Range  Range           `json:"range"`
+	Task   String          `json:"task"`
+	Vars   map[string]*Var `json:"vars,omitempty"`
+	For    *For            `json:"for,omitempty"`
+	Silent *Bool           `json:"silent,omitempty"`
+}
+
+// Call is a reference to a task, in a dependency or a command
+type Call struct {
+	Task  string `json:"task"`
+	Range Range  `json:"range"`
+	// Dependencies run before the task, commands call the task in sequence
+	Dep bool `json:"dep"`
+	// Variables passed to the task
+	Vars map[string]*Var `json:"vars"`
+	// Command is the index of the command calling the task, -1 for a dependency
+	Command int `json:"command"`
+}
+
+// Calls returns the tasks called by the deps and the cmds of a task, deferred calls included, in the order of the source
+func (t *Task) Calls() []Call {
+	calls := make([]Call, 0)
+	for _, d := range t.Deps {
+		calls = append(calls, Call{Task: d.Task.Value, Range: d.Task.Range, Dep: true, Vars: d.Vars, Command: -1})
+	}
+	for i := range t.Commands {
+		for cmd := &t.Commands[i]; cmd != nil; cmd = cmd.Defer {
+			if cmd.Task != nil {
+				calls = append(calls, Call{Task: cmd.Task.Value, Range: cmd.Task.Range, Vars: cmd.Vars, Command: i})
+			}
+		}
+	}
+	sort.SliceStable(calls, func(i, j int) bool {
+		a, b := calls[i].Range, calls[j].Range
+		return a[0] < b[0] || a[0] == b[0] && a[1] < b[1]
+	})
+	return calls
+}
+
+// Precondition is an item of the preconditions of a task
+type Precondition struct {
+	Range Range   `json:"range"`
+	Sh    String  `json:"sh"`
+	Msg   *String `json:"msg,omitempty"`
+}
+
+// nodeStart returns the first token of a node, the key of a mapping entry or of the first entry of a block mapping
+func nodeStart(node ast.Node) *token.Token {
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		return n.Key.GetToken()
+	case *ast.MappingNode:
+		if !n.IsFlowStyle && len(n.Values) > 0 {
+			return n.Values[0].Key.GetToken()
+		}
+	}
+	return node.GetToken()
+}
+
+// NodeRange returns the range of a node, from its first token to its end
+func NodeRange(node ast.Node, src *Source) Range {
+	line, col := src.TokenStart(nodeStart(node))
+	res := Analyze(node, src)
+	return src.NewRange(line, col, res.EndLine, res.EndCol)
+}
+
+// newString returns the string of a scalar node, nil for any other node
+func newString(node ast.Node, src *Source) *String {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		return &String{Value: n.Value, Range: src.ScalarRange(n)}
+	case *ast.LiteralNode:
+		return &String{Value: ScalarValue(n), Range: NodeRange(n, src)}
+	case *ast.NullNode:
+		return nil
+	case ast.ScalarNode:
+		return &String{Value: ScalarValue(n), Range: src.TokenRange(n.GetToken())}
+	}
+	return nil
+}
+
+// newBool returns the boolean of a node, nil if it is not a boolean
+func newBool(node ast.Node, src *Source) *Bool {
+	if n, ok := node.(*ast.BoolNode); ok {
+		return &Bool{Value: n.Value, Range: src.TokenRange(n.Token)}
+	}
+	return nil
+}
+
+// newStrings returns the strings of a scalar or of a sequence, the items that are not scalars are ignored
+func newStrings(node ast.Node, src *Source) []String {
+	strs := make([]String, 0)
+	for _, item := range sequenceItems(node) {
+		if s := newString(item, src); s != nil {
+			strs = append(strs, *s)
+		}
+	}
+	return strs
+}
+
+// newVars returns the variables of a mapping passed to a task or to an include
+func newVars(node ast.Node, src *Source) map[string]*Var {
+	vars := make(map[string]*Var)
+	entries, _ := mappingEntries(node)
+	for _, e := range entries {
+		if _, ok := e.Key.(*ast.StringNode); ok {
+			name, v := ExtractVarFromMappingValueNode(e, src)
+			vars[name] = v
+		}
+	}
+	return vars
+}
+
+// sequenceItems returns the items of a sequence, or the node itself if it is not a sequence
+func sequenceItems(node ast.Node) []ast.Node {
+	if seq, ok := node.(*ast.SequenceNode); ok {
+		return seq.Values
+	}
+	if node == nil {
+		return nil
+	}
+	return []ast.Node{node}
+}
+
+// ExtractCommands returns the commands of the cmds of a task, a single string is the only command
+func ExtractCommands(node ast.Node, src *Source) []Command {
+	commands := make([]Command, 0)
+	for _, item := range sequenceItems(node) {
+		if cmd := newCommand(item, src); cmd != nil {
+			commands = append(commands, *cmd)
+		}
+	}
+	return commands
+}
+
+func newCommand(node ast.Node, src *Source) *Command {
+	if s := newString(node, src); s != nil {
+		return &Command{Range: NodeRange(node, src), Cmd: s}
+	}
+	entries, ok := mappingEntries(node)
+	if !ok {
+		return nil
+	}
+	cmd := &Command{Range: NodeRange(node, src)}
+	for _, e := range entries {
+		switch ScalarValue(e.Key) {
+		case "cmd":
+			cmd.Cmd = newString(e.Value, src)
+		case "task":
+			cmd.Task = newString(e.Value, src)
+		case "vars":
+			cmd.Vars = newVars(e.Value, src)
+		case "defer":
+			cmd.Defer = newCommand(e.Value, src)
+		case "for":
+			cmd.For = newFor(e.Value, src)
+		case "silent":
+			cmd.Silent = newBool(e.Value, src)
+		case "ignore_error":
+			cmd.IgnoreError = newBool(e.Value, src)
+		case "platforms":
+			cmd.Platforms = newStrings(e.Value, src)
+		}
+	}
+	return cmd
+}
+
+func newFor(node ast.Node, src *Source) *For {
+	loop := &For{Range: NodeRange(node, src)}
+	switch node.(type) {
+	case *ast.SequenceNode:
+		loop.Items = newStrings(node, src)
+		return loop
+	case ast.ScalarNode:
+		loop.Files = newString(node, src)
+		return loop
+	}
+	entries, _ := mappingEntries(node)
+	for _, e := range entries {
+		switch ScalarValue(e.Key) {
+		case "var":
+			loop.Var = newString(e.Value, src)
+		case "split":
+			loop.Split = newString(e.Value, src)
+		case "as":
+			loop.As = newString(e.Value, src)
+		}
+	}
+	return loop
+}
+
+// ExtractDeps returns the dependencies of a task
+func ExtractDeps(node ast.Node, src *Source) []Dep {
+	deps := make([]Dep, 0)
+	for _, item := range sequenceItems(node) {
+		if s := newString(item, src); s != nil {
+			deps = append(deps, Dep{Range: NodeRange(item, src), Task: *s})
+			continue
+		}
+		entries, _ := mappingEntries(item)
+		dep := Dep{Range: NodeRange(item, src)}
+		found := false
+		for _, e := range entries {
+			switch ScalarValue(e.Key) {
+			case "task":
+				if s := newString(e.Value, src); s != nil {
+					dep.Task, found = *s, true
+				}
+			case "vars":
+				dep.Vars = newVars(e.Value, src)
+			case "for":
+				dep.For = newFor(e.Value, src)
+			case "silent":
+				dep.Silent = newBool(e.Value, src)
+			}
+		}
+		if found {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// ExtractPreconditions returns the preconditions of a task, a string is a shell command
+func ExtractPreconditions(node ast.Node, src *Source) []Precondition {
+	preconditions := make([]Precondition, 0)
+	for _, item := range sequenceItems(node) {
+		if s := newString(item, src); s != nil {
+			preconditions = append(preconditions, Precondition{Range: NodeRange(item, src), Sh: *s})
+			continue
+		}
+		entries, _ := mappingEntries(item)
+		p := Precondition{Range: NodeRange(item, src)}
+		found := false
+		for _, e := range entries {
+			switch ScalarValue(e.Key) {
+			case "sh":
+				if s := newString(e.Value, src); s != nil {
+					p.Sh, found = *s, true
+				}
+			case "msg":
+				p.Msg = newString(e.Value, src)
+			}
+		}
+		if found {
+			preconditions = append(preconditions, p)
+		}
+	}
+	return preconditions
+}
+
+// ExtractRequires returns the names of the variables required by a task
+func ExtractRequires(node ast.Node, src *Source) []String {
+	entries, _ := mappingEntries(node)
+	for _, e := range entries {
+		if ScalarValue(e.Key) == "vars" {
+			return newStrings(e.Value, src)
+		}
+	}
+	return make([]String, 0)
+}
+
+// extractTaskProperties fills the typed properties of a task
+func extractTaskProperties(task *Task, props []*ast.MappingValueNode, src *Source) {
+	for _, p := range props {
+		switch ScalarValue(p.Key) {
+		case "desc":
+			task.Desc = newString(p.Value, src)
+		case "summary":
+			task.Summary = newString(p.Value, src)
+		case "label":
+			task.Label = newString(p.Value, src)
+		case "aliases":
+			task.Aliases = newStrings(p.Value, src)
+		case "deps":
+			task.Deps = ExtractDeps(p.Value, src)
+		case "cmds":
+			task.Commands = ExtractCommands(p.Value, src)
+			seq, ok := p.Value.(*ast.SequenceNode)
+			task.CmdsFlow = ok && seq.IsFlowStyle
+			switch p.Value.(type) {
+			case *ast.StringNode, *ast.LiteralNode:
+				task.CmdsString = true
+			}
+		case "sources":
+			task.Sources = newStrings(p.Value, src)
+		case "generates":
+			task.Generates = newStrings(p.Value, src)
+		case "status":
+			task.Status = newStrings(p.Value, src)
+		case "preconditions":
+			task.Preconditions = ExtractPreconditions(p.Value, src)
+		case "requires":
+			task.Requires = ExtractRequires(p.Value, src)
+		case "dir":
+			task.Dir = newString(p.Value, src)
+		case "method":
+			task.Method = newString(p.Value, src)
+		case "prefix":
+			task.Prefix = newString(p.Value, src)
+		case "run":
+			task.Run = newString(p.Value, src)
+		case "platforms":
+			task.Platforms = newStrings(p.Value, src)
+		case "dotenv":
+			task.Dotenv = newStrings(p.Value, src)
+		case "set":
+			task.Set = newStrings(p.Value, src)
+		case "shopt":
+			task.Shopt = newStrings(p.Value, src)
+		case "silent":
+			task.Silent = newBool(p.Value, src)
+		case "ignore_error":
+			task.IgnoreError = newBool(p.Value, src)
+		case "internal":
+			task.Internal = newBool(p.Value, src)
+		case "interactive":
+			task.Interactive = newBool(p.Value, src)
+		}
+	}
+}
+
+// extractTaskfileProperty fills a typed property of the Taskfile, the other entries are ignored
+func extractTaskfileProperty(t *Taskfile, key string, node ast.Node, src *Source) {
+	switch key {
+	case "output":
+		t.Output = newString(node, src)
+	case "method":
+		t.Method = newString(node, src)
+	case "run":
+		t.Run = newString(node, src)
+	case "interval":
+		t.Interval = newString(node, src)
+	case "expansions":
+		t.Expansions = newString(node, src)
+	case "silent":
+		t.Silent = newBool(node, src)
+	case "dotenv":
+		t.Dotenv = newStrings(node, src)
+	case "set":
+		t.Set = newStrings(node, src)
+	case "shopt":
+		t.Shopt = newStrings(node, src)
+	}
+}
@@ -0,0 +1,159 @@
+package taskfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseFixture parses a Taskfile from a store, its includes are not read
+func parseFixture(t *testing.T, text string) *Taskfile {
+	t.Helper()
+	store := NewStore()
+	store.Open("/fixture/Taskfile.yml", 1, text)
+	tf := store.Get("/fixture/Taskfile.yml")
+	if tf == nil {
+		t.Fatal("the fixture can not be parsed")
+	}
+	return tf
+}
+
+const v2Fixture = `version: '2'
+expansions: 3
+output: prefixed
+
+includes:
+  docs: ./docs
+
+vars:
+  GREETING: hello
+
+tasks:
+  build:
+    desc: Build it
+    deps: [lint, {task: test, vars: {MODE: fast}}]
+    cmds:
+      - echo {{.GREETING}}
+      - task: docs:serve
+    sources:
+      - ./**/*.go
+    method: checksum
+    silent: true
+`
+
+const v3Fixture = `version: '3'
+
+includes:
+  lib:
+    taskfile: ./lib
+    dir: ./lib
+    optional: true
+    vars:
+      TARGET: linux
+
+tasks:
+  build:
+    aliases: [b]
+    deps:
+      - for: [a, b]
+        task: compile
+        vars:
+          FILE: '{{.ITEM}}'
+    cmds:
+      - cmd: go build
+        platforms: [linux]
+      - defer: {task: cleanup}
+      - for: {var: FILES, split: ',', as: F}
+        cmd: echo {{.F}}
+    requires:
+      vars: [TARGET]
+    preconditions:
+      - sh: test -f go.mod
+        msg: Not a module
+    internal: true
+`
+
+func TestParseV2(t *testing.T) {
+	tf := parseFixture(t, v2Fixture)
+	if tf.Version != "2" || tf.Expansions.String() != "3" || tf.Output.String() != "prefixed" {
+		t.Errorf("settings = %s, %s, %s", tf.Version, tf.Expansions.String(), tf.Output.String())
+	}
+	if include, ok := tf.Includes["docs"]; !ok || include.Taskfile.Value != "./docs" {
+		t.Errorf("include docs = %+v", include)
+	}
+	if _, ok := tf.Vars["GREETING"]; !ok {
+		t.Error("variable GREETING is missing")
+	}
+	task := tf.Tasks["build"]
+	if task == nil {
+		t.Fatal("task build is missing")
+	}
+	if task.Desc.String() != "Build it" || task.Method.String() != "checksum" || task.Silent == nil || !task.Silent.Value {
+		t.Errorf("properties = %s, %s, %v", task.Desc.String(), task.Method.String(), task.Silent)
+	}
+	if len(task.Deps) != 2 || task.Deps[0].Task.Value != "lint" || task.Deps[1].Task.Value != "test" {
+		t.Fatalf("deps = %+v", task.Deps)
+	}
+	if v, ok := task.Deps[1].Vars["MODE"]; !ok || v.Value != "fast" {
+		t.Errorf("vars of dep test = %+v", task.Deps[1].Vars)
+	}
+	if len(task.Commands) != 2 || task.Commands[0].Shell() != "echo {{.GREETING}}" || task.Commands[1].Task.String() != "docs:serve" {
+		t.Errorf("commands = %+v", task.Commands)
+	}
+	if len(task.Sources) != 1 || task.Sources[0].Value != "./**/*.go" {
+		t.Errorf("sources = %+v", task.Sources)
+	}
+	// The range of a string excludes its quotes
+	if want := (Range{13, 11, 13, 15}); !reflect.DeepEqual(task.Deps[0].Task.Range, want) {
+		t.Errorf("range of dep lint = %v, want %v", task.Deps[0].Task.Range, want)
+	}
+}
+
+func TestParseV3(t *testing.T) {
+	tf := parseFixture(t, v3Fixture)
+	if tf.Version != "3" || tf.Expansions != nil {
+		t.Errorf("version = %s, expansions = %v", tf.Version, tf.Expansions)
+	}
+	include := tf.Includes["lib"]
+	if include == nil || include.Taskfile.Value != "./lib" || include.Dir.String() != "./lib" || !include.Optional.Value {
+		t.Fatalf("include lib = %+v", include)
+	}
+	if v, ok := include.Vars["TARGET"]; !ok || v.Value != "linux" {
+		t.Errorf("vars of include lib = %+v", include.Vars)
+	}
+	task := tf.Tasks["build"]
+	if task == nil {
+		t.Fatal("task build is missing")
+	}
+	if len(task.Aliases) != 1 || task.Aliases[0].Value != "b" || !task.Internal.Value {
+		t.Errorf("aliases = %+v, internal = %v", task.Aliases, task.Internal)
+	}
+	if len(task.Deps) != 1 || task.Deps[0].Task.Value != "compile" {
+		t.Fatalf("deps = %+v", task.Deps)
+	}
+	loop := task.Deps[0].For
+	if loop == nil || len(loop.Items) != 2 || loop.Items[0].Value != "a" || loop.Items[1].Value != "b" {
+		t.Errorf("for of dep compile = %+v", loop)
+	}
+	if len(task.Commands) != 3 {
+		t.Fatalf("commands = %+v", task.Commands)
+	}
+	if cmd := task.Commands[0]; cmd.Cmd.String() != "go build" || len(cmd.Platforms) != 1 || cmd.Shell() != "" {
+		t.Errorf("command 0 = %+v", cmd)
+	}
+	if cmd := task.Commands[1]; cmd.Defer == nil || cmd.Defer.Task.String() != "cleanup" {
+		t.Errorf("command 1 = %+v", cmd)
+	}
+	if loop := task.Commands[2].For; loop == nil || loop.Var.String() != "FILES" || loop.Split.String() != "," || loop.As.String() != "F" {
+		t.Errorf("for of command 2 = %+v", loop)
+	}
+	if len(task.Requires) != 1 || task.Requires[0].Value != "TARGET" {
+		t.Errorf("requires = %+v", task.Requires)
+	}
+	if len(task.Preconditions) != 1 || task.Preconditions[0].Sh.Value != "test -f go.mod" || task.Preconditions[0].Msg.String() != "Not a module" {
+		t.Errorf("preconditions = %+v", task.Preconditions)
+	}
+	calls := task.Calls()
+	if len(calls) != 2 || calls[0].Task != "compile" || !calls[0].Dep || calls[1].Task != "cleanup" || calls[1].Command != 1 {
+		t.Errorf("calls = %+v", calls)
+	}
+}
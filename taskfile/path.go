@@ -0,0 +1,134 @@
+package taskfile
+
+import (
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// TaskfileNames are the names of the Taskfiles looked up in a directory, by order of priority
+var TaskfileNames = []string{
+	"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml",
+	"Taskfile.dist.yml", "taskfile.dist.yml", "Taskfile.dist.yaml", "taskfile.dist.yaml",
+}
+
+type PathKind string
+
+const (
+	// The Taskfile of an include, a file or a directory holding a Taskfile
+	PathInclude PathKind = "include"
+	PathDotenv  PathKind = "dotenv"
+	// The directory of a task or of an include
+	PathDir      PathKind = "dir"
+	PathSource   PathKind = "source"
+	PathGenerate PathKind = "generate"
+)
+
+// Path is a path written in a Taskfile
+type Path struct {
+	Kind  PathKind `json:"kind"`
+	Value string   `json:"value"`
+	Range Range    `json:"range"`
+	// Dir is the directory of the task holding the path, the sources and the dotenv files are relative to it
+	Dir string `json:"dir"`
+}
+
+// ScalarRange returns the range of the content of a scalar, without its quotes
+func (s *Source) ScalarRange(sn *ast.StringNode) Range {
+	startLine, startCol := s.TokenStart(sn.Token)
+	endLine, endCol := s.TokenEnd(sn.Token)
+	if sn.Token.Type == token.SingleQuoteType || sn.Token.Type == token.DoubleQuoteType {
+		startCol++
+		endCol--
+	}
+	return s.NewRange(startLine, startCol, endLine, endCol)
+}
+
+// newPath returns the path held by a scalar, templates can not be resolved and are ignored
+func newPath(kind PathKind, node ast.Node, src *Source) (Path, bool) {
+	sn, ok := node.(*ast.StringNode)
+	if !ok || sn.Value == "" || strings.Contains(sn.Value, "{{") {
+		return Path{}, false
+	}
+	return Path{Kind: kind, Value: sn.Value, Range: src.ScalarRange(sn)}, true
+}
+
+// listPaths returns the paths of a scalar or of a sequence of scalars
+// Exclusions of the sources starting with `!` are ignored
+func listPaths(kind PathKind, node ast.Node, src *Source) []Path {
+	paths := make([]Path, 0)
+	items := []ast.Node{node}
+	if seq, ok := node.(*ast.SequenceNode); ok {
+		items = seq.Values
+	}
+	for _, item := range items {
+		p, ok := newPath(kind, item, src)
+		if ok && !strings.HasPrefix(p.Value, "!") {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// ExtractIncludePaths returns the Taskfiles and the directories of the includes
+func ExtractIncludePaths(node ast.Node, src *Source) []Path {
+	paths := make([]Path, 0)
+	includes, _ := mappingEntries(node)
+	for _, include := range includes {
+		// An include is either the path of the Taskfile or a mapping
+		if p, ok := newPath(PathInclude, include.Value, src); ok {
+			paths = append(paths, p)
+			continue
+		}
+		props, _ := mappingEntries(include.Value)
+		for _, prop := range props {
+			kind := PathInclude
+			switch ScalarValue(prop.Key) {
+			case "taskfile":
+			case "dir":
+				kind = PathDir
+			default:
+				continue
+			}
+			if p, ok := newPath(kind, prop.Value, src); ok {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
+
+// ExtractTaskPaths returns the paths of the properties of a task
+func ExtractTaskPaths(node ast.Node, src *Source) []Path {
+	paths := make([]Path, 0)
+	props, _ := mappingEntries(node)
+	dir := ""
+	for _, prop := range props {
+		if ScalarValue(prop.Key) == "dir" {
+			if p, ok := newPath(PathDir, prop.Value, src); ok {
+				dir = p.Value
+				paths = append(paths, p)
+			}
+		}
+	}
+	for _, prop := range props {
+		switch ScalarValue(prop.Key) {
+		case "dotenv":
+			for _, p := range listPaths(PathDotenv, prop.Value, src) {
+				p.Dir = dir
+				paths = append(paths, p)
+			}
+		case "sources", "generates":
+			kind := PathSource
+			if ScalarValue(prop.Key) == "generates" {
+				kind = PathGenerate
+			}
+			for _, p := range listPaths(kind, prop.Value, src) {
+				p.Dir = dir
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
@@ -0,0 +1,224 @@
+package taskfile
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// PositionEncoding is the unit used to count the characters of a line in a position
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#positionEncodingKind
+type PositionEncoding string
+
+const (
+	UTF8  PositionEncoding = "utf-8"
+	UTF16 PositionEncoding = "utf-16"
+	UTF32 PositionEncoding = "utf-32"
+)
+
+// NegotiateEncoding picks the first encoding supported by both the client and the server
+// The client lists its encodings by order of preference, UTF-16 is used if none match
+func NegotiateEncoding(encodings []string) PositionEncoding {
+	for _, e := range encodings {
+		switch PositionEncoding(e) {
+		case UTF8, UTF16, UTF32:
+			return PositionEncoding(e)
+		}
+	}
+	return UTF16
+}
+
+// runeLength returns the number of units used by a rune in a given encoding
+func runeLength(r rune, enc PositionEncoding) int {
+	switch enc {
+	case UTF8:
+		return utf8.RuneLen(r)
+	case UTF32:
+		return 1
+	}
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// EncodeColumn converts a byte column of a line to a column in the given encoding
+func EncodeColumn(line string, byteCol int, enc PositionEncoding) int {
+	if byteCol > len(line) {
+		byteCol = len(line)
+	}
+	if enc == UTF8 {
+		return byteCol
+	}
+	col := 0
+	for _, r := range line[:byteCol] {
+		col += runeLength(r, enc)
+	}
+	return col
+}
+
+// DecodeColumn converts a column in the given encoding to a byte column of a line
+// A column in the middle of a character points to the end of this character
+func DecodeColumn(line string, col int, enc PositionEncoding) int {
+	units := 0
+	for i, r := range line {
+		if units >= col {
+			return i
+		}
+		units += runeLength(r, enc)
+	}
+	return len(line)
+}
+
+// RuneColumn converts a column counted in runes to a byte column of a line
+func RuneColumn(line string, runeCol int) int {
+	return DecodeColumn(line, runeCol, UTF32)
+}
+
+// Source holds the text of a Taskfile and converts positions between
+// byte offsets, runes and the encoding negotiated with the client
+type Source struct {
+	Text string
+	// Encoding is the encoding of the columns of the ranges made from the source
+	Encoding PositionEncoding
+	// Byte offset of the first character of each line
+	lines []int
+}
+
+func NewSource(text string, enc PositionEncoding) *Source {
+	lines := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return &Source{Text: text, Encoding: enc, lines: lines}
+}
+
+// LineCount returns the number of lines in the source
+func (s *Source) LineCount() int {
+	return len(s.lines)
+}
+
+// Line returns the content of a line without its line break
+func (s *Source) Line(n int) string {
+	if n < 0 || n >= len(s.lines) {
+		return ""
+	}
+	end := len(s.Text)
+	if n+1 < len(s.lines) {
+		end = s.lines[n+1] - 1
+	}
+	return strings.TrimSuffix(s.Text[s.lines[n]:end], "\r")
+}
+
+// Offset converts a line and a column in the encoding of the source to a byte offset
+func (s *Source) Offset(line int, col int) int {
+	if line < 0 {
+		return 0
+	}
+	if line >= len(s.lines) {
+		return len(s.Text)
+	}
+	return s.lines[line] + DecodeColumn(s.Line(line), col, s.Encoding)
+}
+
+// Position converts a byte offset to a line and a column in the encoding of the source
+func (s *Source) Position(offset int) (int, int) {
+	if offset > len(s.Text) {
+		offset = len(s.Text)
+	}
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset }) - 1
+	return line, s.Column(line, offset-s.lines[line])
+}
+
+// Column converts a byte column of a line to a column in the encoding of the source
+func (s *Source) Column(line int, byteCol int) int {
+	return EncodeColumn(s.Line(line), byteCol, s.Encoding)
+}
+
+// NewRange creates a Range in the encoding of the source from byte columns
+func (s *Source) NewRange(startLine int, startCol int, endLine int, endCol int) Range {
+	return []int{
+		startLine,
+		s.Column(startLine, startCol),
+		endLine,
+		s.Column(endLine, endCol),
+	}
+}
+
+// TokenStart returns the line and byte column where a token starts
+// goccy positions are 1-based and count runes
+func (s *Source) TokenStart(tk *token.Token) (int, int) {
+	line := tk.Position.Line - 1
+	return line, RuneColumn(s.Line(line), tk.Position.Column-1)
+}
+
+// TokenEnd returns the line and byte column where a single line token ends
+// Quoted tokens include their quotes
+func (s *Source) TokenEnd(tk *token.Token) (int, int) {
+	line, col := s.TokenStart(tk)
+	text := s.Line(line)
+	if col >= len(text) {
+		return line, col
+	}
+	switch tk.Type {
+	case token.SingleQuoteType, token.DoubleQuoteType:
+		quote := text[col]
+		for i := col + 1; i < len(text); i++ {
+			if tk.Type == token.DoubleQuoteType && text[i] == '\\' {
+				i++
+				continue
+			}
+			if text[i] == quote {
+				// Two single quotes are an escaped quote
+				if tk.Type == token.SingleQuoteType && i+1 < len(text) && text[i+1] == quote {
+					i++
+					continue
+				}
+				return line, i + 1
+			}
+		}
+		return line, len(text)
+	}
+	end := col + len(tk.Value)
+	if end > len(text) {
+		end = len(text)
+	}
+	return line, end
+}
+
+// TokenRange returns the Range covered by a single line token
+func (s *Source) TokenRange(tk *token.Token) Range {
+	startLine, startCol := s.TokenStart(tk)
+	endLine, endCol := s.TokenEnd(tk)
+	return s.NewRange(startLine, startCol, endLine, endCol)
+}
+
+// Indentation returns the number of spaces at the start of a line
+func Indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// BlockScalarEnd returns the last line of a block scalar (`|` or `>`) given the line of its header
+// The content of the block is indented at least as deep as its first line
+func (s *Source) BlockScalarEnd(header int) int {
+	content := Indentation(s.Line(header)) + 1
+	end := header
+	for l := header + 1; l < s.LineCount(); l++ {
+		text := s.Line(l)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if Indentation(text) < content {
+			break
+		}
+		if end == header {
+			content = Indentation(text)
+		}
+		end = l
+	}
+	return end
+}
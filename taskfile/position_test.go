@@ -0,0 +1,176 @@
+package taskfile
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/token"
+)
+
+// "é" is 2 bytes and 1 UTF-16 unit, "😀" is 4 bytes and 2 UTF-16 units
+const multibyteLine = "é😀a"
+
+func TestEncodeColumn(t *testing.T) {
+	tests := []struct {
+		line    string
+		byteCol int
+		enc     PositionEncoding
+		want    int
+	}{
+		{multibyteLine, 0, UTF16, 0},
+		{multibyteLine, 2, UTF8, 2},
+		{multibyteLine, 2, UTF16, 1},
+		{multibyteLine, 2, UTF32, 1},
+		{multibyteLine, 6, UTF8, 6},
+		{multibyteLine, 6, UTF16, 3},
+		{multibyteLine, 6, UTF32, 2},
+		{multibyteLine, 7, UTF16, 4},
+		{multibyteLine, 7, UTF32, 3},
+		// Columns past the end of the line are clamped
+		{multibyteLine, 10, UTF16, 4},
+		{"café", 5, UTF16, 4},
+		{"ascii", 3, UTF16, 3},
+	}
+	for _, tt := range tests {
+		if got := EncodeColumn(tt.line, tt.byteCol, tt.enc); got != tt.want {
+			t.Errorf("EncodeColumn(%q, %d, %s) = %d, want %d", tt.line, tt.byteCol, tt.enc, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeColumn(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		enc  PositionEncoding
+		want int
+	}{
+		{multibyteLine, 0, UTF16, 0},
+		{multibyteLine, 1, UTF16, 2},
+		{multibyteLine, 3, UTF16, 6},
+		{multibyteLine, 4, UTF16, 7},
+		// The middle of a surrogate pair points to the end of the emoji
+		{multibyteLine, 2, UTF16, 6},
+		{multibyteLine, 2, UTF32, 6},
+		{multibyteLine, 6, UTF8, 6},
+		// The middle of the bytes of a character points to its end
+		{multibyteLine, 3, UTF8, 6},
+		// Columns past the end of the line are clamped
+		{multibyteLine, 9, UTF16, 7},
+		{"café", 4, UTF16, 5},
+	}
+	for _, tt := range tests {
+		if got := DecodeColumn(tt.line, tt.col, tt.enc); got != tt.want {
+			t.Errorf("DecodeColumn(%q, %d, %s) = %d, want %d", tt.line, tt.col, tt.enc, got, tt.want)
+		}
+	}
+}
+
+// multibyteText has its second line starting at byte 6, the emoji covers the bytes 9 to 12
+const multibyteText = "a: é\nb: 😀x\n"
+
+func TestSourceOffset(t *testing.T) {
+	tests := []struct {
+		enc       PositionEncoding
+		line, col int
+		want      int
+	}{
+		{UTF16, 0, 4, 5},
+		{UTF16, 1, 3, 9},
+		{UTF16, 1, 5, 13},
+		{UTF16, 1, 6, 14},
+		{UTF32, 1, 4, 13},
+		{UTF8, 1, 7, 13},
+		{UTF16, -1, 0, 0},
+		{UTF16, 5, 0, len(multibyteText)},
+	}
+	for _, tt := range tests {
+		src := NewSource(multibyteText, tt.enc)
+		if got := src.Offset(tt.line, tt.col); got != tt.want {
+			t.Errorf("%s Offset(%d, %d) = %d, want %d", tt.enc, tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestSourcePosition(t *testing.T) {
+	tests := []struct {
+		enc       PositionEncoding
+		offset    int
+		line, col int
+	}{
+		{UTF16, 5, 0, 4},
+		{UTF16, 13, 1, 5},
+		{UTF32, 13, 1, 4},
+		{UTF8, 13, 1, 7},
+		{UTF16, 14, 1, 6},
+		// Offsets past the end of the text are clamped
+		{UTF16, 100, 2, 0},
+	}
+	for _, tt := range tests {
+		src := NewSource(multibyteText, tt.enc)
+		line, col := src.Position(tt.offset)
+		if line != tt.line || col != tt.col {
+			t.Errorf("%s Position(%d) = %d:%d, want %d:%d", tt.enc, tt.offset, line, col, tt.line, tt.col)
+		}
+		// Positions map back to the offsets they come from
+		if tt.offset <= len(multibyteText) {
+			if got := src.Offset(line, col); got != tt.offset {
+				t.Errorf("%s Offset(Position(%d)) = %d", tt.enc, tt.offset, got)
+			}
+		}
+	}
+}
+
+func TestTokenStartEnd(t *testing.T) {
+	text := "key: 'é😀'\nnom: \"x😀y\"\n名前: café\n"
+	tests := []struct {
+		value      string
+		start, end [2]int
+	}{
+		{"é😀", [2]int{0, 5}, [2]int{0, 13}},
+		{"x😀y", [2]int{1, 5}, [2]int{1, 13}},
+		{"名前", [2]int{2, 0}, [2]int{2, 6}},
+		{"café", [2]int{2, 8}, [2]int{2, 13}},
+	}
+	src := NewSource(text, UTF16)
+	tokens := lexer.Tokenize(text)
+	for _, tt := range tests {
+		var tk *token.Token
+		for _, candidate := range tokens {
+			if candidate.Value == tt.value {
+				tk = candidate
+				break
+			}
+		}
+		if tk == nil {
+			t.Errorf("no token %q", tt.value)
+			continue
+		}
+		if line, col := src.TokenStart(tk); line != tt.start[0] || col != tt.start[1] {
+			t.Errorf("TokenStart(%q) = %d:%d, want %d:%d", tt.value, line, col, tt.start[0], tt.start[1])
+		}
+		if line, col := src.TokenEnd(tk); line != tt.end[0] || col != tt.end[1] {
+			t.Errorf("TokenEnd(%q) = %d:%d, want %d:%d", tt.value, line, col, tt.end[0], tt.end[1])
+		}
+	}
+}
+
+func TestTokenRangeEncoding(t *testing.T) {
+	text := "名前: café\n"
+	tokens := lexer.Tokenize(text)
+	tk := tokens[len(tokens)-1]
+	tests := []struct {
+		enc  PositionEncoding
+		want Range
+	}{
+		{UTF8, Range{0, 8, 0, 13}},
+		{UTF16, Range{0, 4, 0, 8}},
+		{UTF32, Range{0, 4, 0, 8}},
+	}
+	for _, tt := range tests {
+		got := NewSource(text, tt.enc).TokenRange(tk)
+		if len(got) != 4 || got[0] != tt.want[0] || got[1] != tt.want[1] || got[2] != tt.want[2] || got[3] != tt.want[3] {
+			t.Errorf("%s TokenRange(%q) = %v, want %v", tt.enc, tk.Value, got, tt.want)
+		}
+	}
+}
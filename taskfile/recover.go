@@ -0,0 +1,131 @@
+package taskfile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// maxRecoveries is the number of lines blanked before giving up on a document
+const maxRecoveries = 20
+
+// SyntaxError is a line of a Taskfile ignored because it is not valid YAML
+type SyntaxError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// syntaxErrorMessage matches the position prefixing the errors of goccy
+var syntaxErrorMessage = regexp.MustCompile(`^\[(\d+):\d+\]\s*`)
+
+// errEmptyDocument is the error of the documents without any node
+var errEmptyDocument = fmt.Errorf("empty document")
+
+// parseDocument parses a YAML document, goccy panics on some invalid documents
+func parseDocument(contents string) (f *ast.File, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			f, err = nil, fmt.Errorf("the document is not valid YAML")
+		}
+	}()
+	f, err = parseWithoutComments(contents)
+	if err == nil && (len(f.Docs) == 0 || f.Docs[0] == nil) {
+		err = errEmptyDocument
+	}
+	return f, err
+}
+
+// parseWithoutComments parses a YAML document, goccy drops the nodes following a comment when parsing
+// with comments so they are read from the tokens instead
+func parseWithoutComments(contents string) (*ast.File, error) {
+	return parser.ParseBytes([]byte(contents), 0)
+}
+
+// parseTolerant parses a document, the lines breaking it are blanked until the rest is valid
+// Blanked lines keep the positions of the other lines, they are returned as syntax errors
+func parseTolerant(contents string) (*ast.File, []SyntaxError, error) {
+	f, err := parseDocument(contents)
+	if err == nil {
+		return f, nil, nil
+	}
+	lines := strings.Split(contents, "\n")
+	errors := make([]SyntaxError, 0)
+	for i := 0; i < maxRecoveries && err != nil; i++ {
+		broken, brokenErr := firstBrokenLine(lines)
+		if broken < 0 {
+			break
+		}
+		message := syntaxErrorMessage.ReplaceAllString(strings.SplitN(brokenErr.Error(), "\n", 2)[0], "")
+		errors = append(errors, SyntaxError{Line: broken, Message: message})
+		lines[broken] = ""
+		f, err = parseDocument(strings.Join(lines, "\n"))
+	}
+	if err != nil {
+		return nil, errors, err
+	}
+	sort.Slice(errors, func(i, j int) bool { return errors[i].Line < errors[j].Line })
+	return f, errors, nil
+}
+
+// firstBrokenLine returns the line from which the beginning of a document stops being valid, -1 if it is valid
+// The validity of the beginnings is not monotonic, a line can close what the previous one opened, so they are parsed
+// line by line. When no beginning fails alone the line of the error of the parser is used
+// The error of the parser is returned with the line
+func firstBrokenLine(lines []string) (int, error) {
+	_, err := parseDocument(strings.Join(lines, "\n"))
+	if err == nil || err == errEmptyDocument {
+		return -1, nil
+	}
+	for n := range lines {
+		// The beginnings holding only comments or blank lines are empty documents
+		if _, prefixErr := parseDocument(strings.Join(lines[:n+1], "\n")); prefixErr != nil && prefixErr != errEmptyDocument {
+			return n, prefixErr
+		}
+	}
+	line := len(lines) - 1
+	if m := syntaxErrorMessage.FindStringSubmatch(err.Error()); m != nil {
+		if l, _ := strconv.Atoi(m[1]); l > 0 && l <= len(lines) {
+			line = l - 1
+		}
+	}
+	return line, err
+}
+
+// mergeSnapshot adds the tasks and the variables of the last valid version of a Taskfile missing from a recovered one
+// The tasks overlapping the tasks of the recovered Taskfile are left out, their ranges are not reliable anymore
+func (t *Taskfile) mergeSnapshot(snapshot *Taskfile) {
+	if snapshot == nil || snapshot.Root == nil {
+		return
+	}
+	if t.Tasks == nil {
+		t.Tasks = make(map[string]*Task)
+	}
+	for name, task := range snapshot.Tasks {
+		if _, ok := t.Tasks[name]; ok || t.overlapsTask(task.Range) {
+			continue
+		}
+		t.Tasks[name] = task
+	}
+	if t.Vars == nil {
+		t.Vars = make(map[string]*Var)
+	}
+	for name, v := range snapshot.Vars {
+		if _, ok := t.Vars[name]; !ok {
+			t.Vars[name] = v
+		}
+	}
+}
+
+func (t *Taskfile) overlapsTask(r Range) bool {
+	for _, task := range t.Tasks {
+		if r[0] <= task.Range[2] && task.Range[0] <= r[2] {
+			return true
+		}
+	}
+	return false
+}
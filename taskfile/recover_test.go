@@ -0,0 +1,90 @@
+package taskfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFirstBrokenLine(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"valid", "a: 1\nb: 2", -1},
+		{"empty", "", -1},
+		{"comments", "# a\n# b", -1},
+		// The parser reports the error on the line of the key before the broken one
+		{"indented key", "a: 1\n b: 2\nc: 3", 1},
+		{"after comments", "# a\n\n# b\na: 1\n b: 2", 4},
+		{"after a block scalar", "a: |\n  x\n\n  y\nb: 1\n c: 2", 5},
+	}
+	for _, tt := range tests {
+		got, err := firstBrokenLine(strings.Split(tt.text, "\n"))
+		if got != tt.want {
+			t.Errorf("%s: firstBrokenLine = %d, want %d", tt.name, got, tt.want)
+		}
+		if (got >= 0) != (err != nil) {
+			t.Errorf("%s: firstBrokenLine returned the error %v with the line %d", tt.name, err, got)
+		}
+	}
+}
+
+func TestParseTolerant(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		lines []int
+	}{
+		{"valid", "a: 1", []int{}},
+		{"one line", "a: 1\n b: 2\nc: 3", []int{1}},
+		{"two lines", "a: 1\n b: 2\nc: 3\n d: 4\ne: 5", []int{1, 3}},
+	}
+	for _, tt := range tests {
+		f, syntaxErrors, err := parseTolerant(tt.text)
+		if err != nil || f == nil {
+			t.Errorf("%s: parseTolerant failed: %v", tt.name, err)
+			continue
+		}
+		lines := make([]int, 0)
+		for _, e := range syntaxErrors {
+			lines = append(lines, e.Line)
+			if e.Message == "" || strings.HasPrefix(e.Message, "[") {
+				t.Errorf("%s: the message of line %d is %q", tt.name, e.Line, e.Message)
+			}
+		}
+		if !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("%s: the lines ignored are %v, want %v", tt.name, lines, tt.lines)
+		}
+	}
+}
+
+func TestStoreKeepsValidSnapshot(t *testing.T) {
+	const path = "/fixture/Taskfile.yml"
+	valid := "version: '3'\ntasks:\n  a:\n    cmds: [echo a]\n  b:\n    cmds: [echo b]\n"
+	// The task b is lost by the recoveries, it comes from the valid version
+	broken := []string{
+		"version: '3'\ntasks:\n  a:\n    cmds: [echo a]\n b: 1\n   c: 2\n",
+		"version: '3'\ntasks:\n  a:\n    cmds: [echo a]\n b: 1\n   c: 3\n",
+	}
+	store := NewStore()
+	store.Open(path, 1, valid)
+	good := store.Get(path)
+	if good == nil || len(good.SyntaxErrors) > 0 {
+		t.Fatal("the valid version is not parsed")
+	}
+	for i, text := range broken {
+		store.Open(path, i+2, text)
+		tf := store.Get(path)
+		if tf == nil || len(tf.SyntaxErrors) == 0 {
+			t.Fatalf("version %d is not recovered", i+2)
+		}
+		if _, ok := tf.Tasks["b"]; !ok {
+			t.Errorf("version %d lost the task b of the valid version", i+2)
+		}
+		if snapshot := store.entries[path].snapshot; snapshot != good {
+			t.Errorf("version %d replaced the snapshot of the valid version", i+2)
+		}
+	}
+}
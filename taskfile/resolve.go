@@ -0,0 +1,147 @@
+package taskfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Value is the value of a variable or of an expression known without running Task
+type Value struct {
+	Text string
+	// Unknown is set when the value depends on a command, on the environment or on the callers of the task
+	Unknown bool
+	// Reason tells why the value is unknown
+	Reason string
+}
+
+func unknownValue(format string, args ...interface{}) Value {
+	return Value{Unknown: true, Reason: fmt.Sprintf(format, args...)}
+}
+
+// Evaluator evaluates the expressions of a task, the variables are resolved once for all of them
+type Evaluator struct {
+	taskfile *Taskfile
+	task     *Task
+	vars     map[string]*ResolvedVar
+	// seen are the variables seen by the declarations holding expressions, before they are set
+	seen   map[*Var]map[string]*ResolvedVar
+	passed map[string]bool
+}
+
+// NewEvaluator resolves the variables of a task of the Taskfile for its expressions, nil for the expressions outside of the tasks
+func (t *Taskfile) NewEvaluator(task *Task) *Evaluator {
+	ev := &Evaluator{taskfile: t, task: task, passed: make(map[string]bool)}
+	exprs := t.Expressions
+	if task != nil {
+		exprs = task.Expressions
+		ev.passed = t.PassedVars(task)
+	}
+	r := &varResolver{vars: make(map[string]*ResolvedVar), seen: make(map[*Var]map[string]*ResolvedVar)}
+	for _, e := range exprs {
+		if v := ev.declaration(e); v != nil {
+			r.seen[v] = nil
+		}
+	}
+	root, inv := t.rootInvocation(task)
+	root.resolve(r, inv)
+	ev.vars, ev.seen = r.vars, r.seen
+	return ev
+}
+
+// declaration returns the declaration holding an expression in its value, nil if there is none
+func (ev *Evaluator) declaration(e Expr) *Var {
+	var decl *Var
+	if ev.task != nil {
+		for _, v := range ev.task.Vars {
+			if IsInRange(e.Range[0], e.Range[1], v.Range) {
+				decl = v
+			}
+		}
+	}
+	for _, v := range ev.taskfile.Vars {
+		if IsInRange(e.Range[0], e.Range[1], v.Range) {
+			decl = v
+		}
+	}
+	return decl
+}
+
+// EvalExpr returns the value of an expression of a task, nil for the expressions outside of the tasks
+// Evaluating several expressions of a task is done with an Evaluator, the variables are resolved once
+func (t *Taskfile) EvalExpr(task *Task, e Expr) (Value, bool) {
+	return t.NewEvaluator(task).Eval(e)
+}
+
+// Eval returns the value of an expression of the task of the evaluator
+// The variables are resolved like Task does, an expression in the value of a variable sees the variables declared before it
+// Returns false if the expression uses an undefined variable or is not a single variable or platform function
+func (ev *Evaluator) Eval(e Expr) (Value, bool) {
+	vars := ev.vars
+	if decl := ev.declaration(e); decl != nil {
+		// The declarations not reached by the resolution see all the variables
+		if seen, ok := ev.seen[decl]; ok && seen != nil {
+			vars = seen
+		}
+	}
+	lookup := func(name string) (Value, bool) {
+		v, ok := vars[name]
+		// The variables passed by the callers override the levels below the task
+		if ok && (v.Source == SourceTask || v.Source == SourceCLI) {
+			return v.Value, true
+		}
+		if ev.passed[name] {
+			return unknownValue("%s is passed by the callers of %s", name, ev.task.Name), true
+		}
+		if ok {
+			return v.Value, true
+		}
+		return Value{}, false
+	}
+	for _, tk := range e.Tokens {
+		switch tk.Kind {
+		case TokenVariable:
+			// Template variables are declared by other actions
+			return Value{}, false
+		case TokenKeyword:
+			if !TemplateLiterals[tk.Value] {
+				return Value{}, false
+			}
+		case TokenField:
+			// Fields of a variable can not be resolved from a string
+			if name := tk.FieldName(); name != strings.TrimPrefix(tk.Value, ".") {
+				return Value{}, false
+			} else if _, ok := lookup(name); !ok {
+				return Value{}, false
+			}
+		}
+	}
+	value, err := renderWith("{{"+e.Value+"}}", lookup)
+	if err != nil {
+		return unknownValue("%s", err.Message), true
+	}
+	return value, true
+}
+
+// TaskVars returns the variables of a task of the Taskfile, nil for the variables of the Taskfile alone
+// An included Taskfile is run by the first root including it, with the variables of the includes
+func (t *Taskfile) TaskVars(task *Task) map[string]*ResolvedVar {
+	root, inv := t.rootInvocation(task)
+	return root.ResolveVars(inv)
+}
+
+// rootInvocation returns the Taskfile running a task of the Taskfile and the invocation of the task
+func (t *Taskfile) rootInvocation(task *Task) (*Taskfile, Invocation) {
+	for _, root := range t.Roots() {
+		if root.Path == t.Path {
+			break
+		}
+		included, _ := root.Included()
+		for _, it := range included {
+			if it.Taskfile.Path == t.Path && (task == nil || it.Task.Name == task.Name) {
+				// The task and the Taskfile are the ones asked for, the declarations are compared
+				return root, Invocation{Task: task, Taskfile: t, Includes: it.Includes}
+			}
+		}
+	}
+	return t, Invocation{Task: task}
+}
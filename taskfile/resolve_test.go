@@ -0,0 +1,75 @@
+package taskfile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEvaluatorEval(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		"Taskfile.yml": `version: '3'
+vars:
+  G: global
+  H: '{{.G}}'
+tasks:
+  build:
+    vars:
+      A: '{{.G}}'
+      G: task
+      B: '{{.G}}'
+    cmds:
+      - echo {{.G}} {{.P}} {{.U}}
+      - task: test
+        vars:
+          P: passed
+          Q: passed
+  test:
+    vars:
+      Q: task
+    cmds:
+      - echo {{.P}} {{.Q}} {{OS}}
+`,
+	})
+	defer os.RemoveAll(dir)
+	tf := NewStore().Get(filepath.Join(dir, "Taskfile.yml"))
+	tests := []struct {
+		task string
+		// index of the expression in the task, or in the Taskfile for an empty task
+		index   int
+		ok      bool
+		unknown bool
+		value   string
+	}{
+		// Declared before the variable of the task
+		{"build", 0, true, false, "global"},
+		{"build", 1, true, false, "task"},
+		{"build", 2, true, false, "task"},
+		{"build", 3, false, false, ""},
+		{"build", 4, false, false, ""},
+		// The value is given by the callers
+		{"test", 0, true, true, ""},
+		// The variables of the task win over the variables passed to it
+		{"test", 1, true, false, "task"},
+		{"test", 2, true, false, runtime.GOOS},
+		{"", 0, true, false, "global"},
+	}
+	for _, tt := range tests {
+		var task *Task
+		exprs := tf.Expressions
+		if tt.task != "" {
+			task = tf.Tasks[tt.task]
+			exprs = task.Expressions
+		}
+		ev := tf.NewEvaluator(task)
+		e := exprs[tt.index]
+		value, ok := ev.Eval(e)
+		if ok != tt.ok || value.Unknown != tt.unknown || !tt.unknown && value.Text != tt.value {
+			t.Errorf("%s: %s = %+v, %v, want %q, %v, unknown %v", tt.task, e.Value, value, ok, tt.value, tt.ok, tt.unknown)
+		}
+		if v, ok := tf.EvalExpr(task, e); v != value || ok != tt.ok {
+			t.Errorf("%s: EvalExpr(%s) = %+v, %v, want the value of the evaluator", tt.task, e.Value, v, ok)
+		}
+	}
+}
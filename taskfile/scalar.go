@@ -0,0 +1,151 @@
+package taskfile
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// ScalarStyle is the way a scalar is written, it tells how its value maps to the document
+type ScalarStyle int
+
+const (
+	StylePlain ScalarStyle = iota
+	StyleSingleQuoted
+	StyleDoubleQuoted
+	// Block scalars, `|` and `>`
+	StyleBlock
+)
+
+// scalarStyle returns the style of the token of a scalar
+func scalarStyle(tk *token.Token) ScalarStyle {
+	switch tk.Type {
+	case token.SingleQuoteType:
+		return StyleSingleQuoted
+	case token.DoubleQuoteType:
+		return StyleDoubleQuoted
+	case token.LiteralType, token.FoldedType:
+		return StyleBlock
+	}
+	return StylePlain
+}
+
+// ScalarMap maps the byte offsets of the value of a scalar to the lines and byte columns of the document
+// Line breaks, indentation, folding and escape sequences make the value differ from the text of the document
+type ScalarMap struct {
+	// Line and byte column of each byte of the value, and of the end of the value
+	positions [][2]int
+}
+
+// MapScalar aligns the value of a scalar with the document, starting at a line and a byte column
+// The indentation of the lines of block scalars is skipped up to indent, the lines of flow scalars are trimmed
+func (s *Source) MapScalar(value string, style ScalarStyle, line int, col int, indent int) *ScalarMap {
+	m := &ScalarMap{positions: make([][2]int, 0, len(value)+1)}
+	l, c := line, col
+	newLine := func() {
+		l++
+		text := s.Line(l)
+		c = 0
+		for c < len(text) && (text[c] == ' ' || text[c] == '\t') && (style != StyleBlock || c < indent) {
+			c++
+		}
+	}
+	for v := 0; v < len(value); {
+		if l >= s.LineCount() {
+			m.positions = append(m.positions, [2]int{l, c})
+			v++
+			continue
+		}
+		text := s.Line(l)
+		switch {
+		case style == StyleDoubleQuoted && c+1 == len(text) && text[c] == '\\' && value[v] != '\\':
+			// An escaped line break is removed
+			newLine()
+		case c >= len(text):
+			// A line break is kept or folded into a space
+			if value[v] == '\n' || value[v] == ' ' {
+				m.positions = append(m.positions, [2]int{l, c})
+				v++
+			}
+			newLine()
+		case style == StyleDoubleQuoted && text[c] == '\\' && value[v] != '\\':
+			n, size := escapeLength(text[c:])
+			for i := 0; i < size && v < len(value); i++ {
+				m.positions = append(m.positions, [2]int{l, c})
+				v++
+			}
+			c += n
+		case text[c] == value[v]:
+			m.positions = append(m.positions, [2]int{l, c})
+			c++
+			v++
+			// Two single quotes are an escaped quote
+			if style == StyleSingleQuoted && text[c-1] == '\'' && c < len(text) && text[c] == '\'' {
+				c++
+			}
+		case value[v] == ' ' || value[v] == '\t' || value[v] == '\n':
+			// Spaces of the value which are not in the document, the parser may keep the indentation
+			m.positions = append(m.positions, [2]int{l, c})
+			v++
+		default:
+			// Characters of the document which are not in the value
+			c++
+		}
+	}
+	m.positions = append(m.positions, [2]int{l, c})
+	return m
+}
+
+// escapeLength returns the number of bytes of the escape sequence starting a text, and of the bytes it stands for
+func escapeLength(text string) (int, int) {
+	if len(text) < 2 {
+		return len(text), 1
+	}
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[text[1]]
+	if digits == 0 {
+		return 2, 1
+	}
+	n := 2 + digits
+	if n > len(text) {
+		n = len(text)
+	}
+	r, err := strconv.ParseUint(text[2:n], 16, 32)
+	if err != nil || digits == 2 {
+		return n, 1
+	}
+	return n, utf8.RuneLen(rune(r))
+}
+
+// Position returns the line and the byte column of an offset of the value
+func (m *ScalarMap) Position(offset int) (int, int) {
+	if offset >= len(m.positions) {
+		offset = len(m.positions) - 1
+	}
+	p := m.positions[offset]
+	return p[0], p[1]
+}
+
+// Range returns the Range covering the bytes of the value between two offsets
+func (m *ScalarMap) Range(src *Source, start int, end int) Range {
+	startLine, startCol := m.Position(start)
+	endLine, endCol := m.Position(end)
+	// The end of a range ending with the last byte of a line is the end of the line
+	if end > start && end < len(m.positions) {
+		if lastLine, lastCol := m.Position(end - 1); lastLine < endLine {
+			endLine, endCol = lastLine, lastCol+1
+		}
+	}
+	return src.NewRange(startLine, startCol, endLine, endCol)
+}
+
+// blockIndent returns the indentation of the content of a block scalar, 0 if it is empty
+func (s *Source) blockIndent(header int, end int) int {
+	for l := header + 1; l <= end; l++ {
+		if text := s.Line(l); strings.TrimSpace(text) != "" {
+			return Indentation(text)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package taskfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rangeText returns the text of the document covered by a range on a single line of ASCII text
+func rangeText(text string, r Range) string {
+	lines := strings.Split(text, "\n")
+	if r[0] != r[2] {
+		return "multiline"
+	}
+	return lines[r[0]][r[1]:r[3]]
+}
+
+func TestBlockScalarExpressions(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		want []string
+	}{
+		{"literal", "|\n      echo {{.A}}\n      echo {{.B}}", []string{".A", ".B"}},
+		{"folded", ">\n      echo {{.A}}\n      {{.B}}", []string{".A", ".B"}},
+		{"strip", "|-\n      echo {{.A}}\n\n      {{.B}}\n", []string{".A", ".B"}},
+		{"keep", ">+\n      echo\n      {{.A}}\n\n", []string{".A"}},
+		// The indentation indicator is relative to the indentation of the item
+		{"indicator", "|2\n      echo {{.A}}\n      {{.B}}", []string{".A", ".B"}},
+		{"indicator before a more indented line", "|2\n        {{.A}}\n      {{.B}}", []string{".A", ".B"}},
+		{"indicator and chomping", ">-2\n      echo {{.A}}", []string{".A"}},
+		// Lines more indented than the first one keep their extra indentation
+		{"more indented", "|\n      echo {{.A}}\n        {{.B}}\n      {{.C}}", []string{".A", ".B", ".C"}},
+		{"more indented folded", ">\n      a\n        {{.A}} {{.B}}\n      {{.C}}", []string{".A", ".B", ".C"}},
+		{"tabs", "|\n      echo\t{{.A}}\t{{.B}}", []string{".A", ".B"}},
+		{"blank lines", "|\n\n      echo {{.A}}\n\n\n      {{.B}}", []string{".A", ".B"}},
+	}
+	for _, tt := range tests {
+		text := "version: '3'\ntasks:\n  a:\n    cmds:\n    - " + tt.cmd + "\n"
+		tf := parseFixture(t, text)
+		task, ok := tf.Tasks["a"]
+		if !ok {
+			t.Errorf("%s: the task is not parsed", tt.name)
+			continue
+		}
+		got := make([]string, 0)
+		for _, e := range task.Expressions {
+			got = append(got, rangeText(text, e.Range))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: the expressions are at %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMapScalar(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		value  string
+		style  ScalarStyle
+		col    int
+		indent int
+		// Byte offsets of the value and their line and byte column
+		offsets   []int
+		positions [][2]int
+	}{
+		{"literal", "|\n  ab\n  c", "ab\nc", StyleBlock, 2, 2, []int{0, 2, 3, 4}, [][2]int{{1, 2}, {1, 4}, {2, 2}, {2, 3}}},
+		{"folded", ">\n  ab\n  c", "ab c", StyleBlock, 2, 2, []int{0, 2, 3, 4}, [][2]int{{1, 2}, {1, 4}, {2, 2}, {2, 3}}},
+		// The indentation past the indicator is part of the value
+		{"indicator", "|2\n    ab\n  c", "  ab\nc", StyleBlock, 2, 2, []int{0, 2, 4, 5}, [][2]int{{1, 2}, {1, 4}, {1, 6}, {2, 2}}},
+		{"double quoted", `"a\tb\
+  c"`, "a\tbc", StyleDoubleQuoted, 1, 0, []int{1, 2, 3}, [][2]int{{0, 2}, {0, 4}, {1, 2}}},
+		{"single quoted", `'it''s'`, "it's", StyleSingleQuoted, 1, 0, []int{2, 3, 4}, [][2]int{{0, 3}, {0, 5}, {0, 6}}},
+	}
+	for _, tt := range tests {
+		src := NewSource(tt.text, UTF8)
+		line := 0
+		if tt.style == StyleBlock {
+			line = 1
+		}
+		m := src.MapScalar(tt.value, tt.style, line, tt.col, tt.indent)
+		for i, offset := range tt.offsets {
+			if l, c := m.Position(offset); l != tt.positions[i][0] || c != tt.positions[i][1] {
+				t.Errorf("%s: offset %d is at %d:%d, want %d:%d", tt.name, offset, l, c, tt.positions[i][0], tt.positions[i][1])
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package taskfile
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VarSource tells which level of Task declares a variable
+type VarSource string
+
+// Levels of the variables, from the lowest to the highest precedence
+const (
+	SourceSpecial VarSource = "special"
+	// SourceEnviron is the environment of the process running Task
+	SourceEnviron VarSource = "environ"
+	SourceDotenv  VarSource = "dotenv"
+	// SourceEnv is the `env` of the Taskfile
+	SourceEnv     VarSource = "env"
+	SourceGlobal  VarSource = "global"
+	SourceInclude VarSource = "include"
+	// SourceIncluded is the `vars` of the included Taskfile defining the task
+	SourceIncluded VarSource = "included"
+	SourceCall     VarSource = "call"
+	SourceTask     VarSource = "task"
+	SourceCLI      VarSource = "cli"
+)
+
+// Invocation is a way a task is run, from the command line or by a call
+type Invocation struct {
+	Task *Task
+	// Taskfile defining the task, nil for the tasks of the Taskfile running them
+	Taskfile *Taskfile
+	// Includes leading to the Taskfile of the task, starting from the Taskfile running it
+	Includes []*Include
+	// Call passing variables to the task, nil when the task is run from the command line
+	Call *Call
+	// Vars are the variables given on the command line
+	Vars map[string]string
+}
+
+// InvocationOf returns the invocation of an included task from a Taskfile
+func InvocationOf(it IncludedTask) Invocation {
+	return Invocation{Task: it.Task, Taskfile: it.Taskfile, Includes: it.Includes}
+}
+
+// ResolvedVar is the effective value of a variable for an invocation
+type ResolvedVar struct {
+	Name   string
+	Value  Value
+	Source VarSource
+	// Decl is the declaration winning, nil for the special variables, the environment and the command line
+	Decl *Var
+	// Overridden are the declarations of lower levels hidden by the winning one
+	Overridden []*Var
+}
+
+// ResolveVars returns the variables of an invocation of a task, the task is nil for the variables of the Taskfile alone
+// Levels are merged in the order of Task, each value is rendered with the variables of the previous declarations
+func (t *Taskfile) ResolveVars(inv Invocation) map[string]*ResolvedVar {
+	r := &varResolver{vars: make(map[string]*ResolvedVar)}
+	t.resolve(r, inv)
+	return r.vars
+}
+
+// DeclaredVars returns the variables of a task of the Taskfile like TaskVars, nil for the variables of the Taskfile alone
+// The values are not rendered and the environment of the process is left out, only the winning levels are known
+func (t *Taskfile) DeclaredVars(task *Task) map[string]*ResolvedVar {
+	root, inv := t.rootInvocation(task)
+	r := &varResolver{vars: make(map[string]*ResolvedVar), declaredOnly: true}
+	root.resolve(r, inv)
+	return r.vars
+}
+
+// resolve merges the levels of an invocation in the order of Task
+func (t *Taskfile) resolve(r *varResolver, inv Invocation) {
+	defining := t
+	if inv.Taskfile != nil {
+		defining = inv.Taskfile
+	}
+	r.special(t, defining, inv.Task)
+	for _, kv := range os.Environ() {
+		if r.declaredOnly {
+			break
+		}
+		if i := strings.Index(kv, "="); i > 0 {
+			r.set(kv[:i], Value{Text: kv[i+1:]}, SourceEnviron, nil)
+		}
+	}
+	// Dotenv files do not override the environment
+	for _, v := range sortedVars(t.DotenvVars(inv.Task)) {
+		if _, ok := r.vars[v.Name]; !ok {
+			r.set(v.Name, Value{Text: v.Value}, SourceDotenv, v)
+		}
+	}
+	r.declare(t.Env, SourceEnv)
+	r.declare(t.Vars, SourceGlobal)
+	for _, include := range inv.Includes {
+		r.declare(include.Vars, SourceInclude)
+	}
+	if inv.Taskfile != nil && inv.Taskfile != t {
+		r.declare(inv.Taskfile.Vars, SourceIncluded)
+	}
+	if inv.Call != nil {
+		r.declare(inv.Call.Vars, SourceCall)
+	}
+	if inv.Task != nil {
+		r.declare(inv.Task.Vars, SourceTask)
+	}
+	names := make([]string, 0, len(inv.Vars))
+	for name := range inv.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.set(name, Value{Text: inv.Vars[name]}, SourceCLI, nil)
+	}
+}
+
+type varResolver struct {
+	vars map[string]*ResolvedVar
+	// seen are the variables seen by the value of a declaration, before it is set, for the declarations
+	// it holds when the resolution starts
+	seen map[*Var]map[string]*ResolvedVar
+	// declaredOnly skips the rendering of the values
+	declaredOnly bool
+}
+
+// special sets the special variables known without running Task
+func (r *varResolver) special(root *Taskfile, defining *Taskfile, task *Task) {
+	for name := range SpecialVars {
+		r.set(name, unknownValue("%s is set by Task when running", name), SourceSpecial, nil)
+	}
+	r.set("ROOT_TASKFILE", Value{Text: root.Path}, SourceSpecial, nil)
+	r.set("ROOT_DIR", Value{Text: filepath.Dir(root.Path)}, SourceSpecial, nil)
+	r.set("TASKFILE", Value{Text: defining.Path}, SourceSpecial, nil)
+	r.set("TASKFILE_DIR", Value{Text: filepath.Dir(defining.Path)}, SourceSpecial, nil)
+	if task != nil {
+		r.set("TASK", Value{Text: task.Name}, SourceSpecial, nil)
+		// The directory of an included task depends on its include
+		switch {
+		case task.Dir != nil && !strings.Contains(task.Dir.Value, "{{"):
+			r.set("TASK_DIR", Value{Text: ResolvePath(filepath.Dir(defining.Path), task.Dir.Value)}, SourceSpecial, nil)
+		case task.Dir == nil && defining == root:
+			r.set("TASK_DIR", Value{Text: filepath.Dir(root.Path)}, SourceSpecial, nil)
+		}
+	}
+}
+
+// declare renders and sets the variables of a level in the order of their declarations
+func (r *varResolver) declare(vars map[string]*Var, source VarSource) {
+	for _, v := range sortedVars(vars) {
+		if _, ok := r.seen[v]; ok {
+			seen := make(map[string]*ResolvedVar, len(r.vars))
+			for name, resolved := range r.vars {
+				seen[name] = resolved
+			}
+			r.seen[v] = seen
+		}
+		value := unknownValue("%s depends on the command `%s`", v.Name, v.Sh)
+		if r.declaredOnly {
+			value = Value{}
+		} else if v.Sh == "" {
+			var err *TemplateError
+			if value, err = renderWith(v.Value, r.lookup); err != nil {
+				value = unknownValue("%s", err.Message)
+			}
+		}
+		r.set(v.Name, value, source, v)
+	}
+}
+
+func (r *varResolver) lookup(name string) (Value, bool) {
+	if v, ok := r.vars[name]; ok {
+		return v.Value, true
+	}
+	return Value{}, false
+}
+
+func (r *varResolver) set(name string, value Value, source VarSource, decl *Var) {
+	overridden := make([]*Var, 0)
+	if previous, ok := r.vars[name]; ok {
+		overridden = previous.Overridden
+		if previous.Decl != nil {
+			overridden = append(overridden, previous.Decl)
+		}
+	}
+	r.vars[name] = &ResolvedVar{Name: name, Value: value, Source: source, Decl: decl, Overridden: overridden}
+}
+
+// sortedVars returns variables in the order of their declarations
+func sortedVars(vars map[string]*Var) []*Var {
+	sorted := make([]*Var, 0, len(vars))
+	for _, v := range vars {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if len(a.NameRange) < 2 || len(b.NameRange) < 2 {
+			return a.Name < b.Name
+		}
+		if a.NameRange[0] != b.NameRange[0] {
+			return a.NameRange[0] < b.NameRange[0]
+		}
+		return a.NameRange[1] < b.NameRange[1]
+	})
+	return sorted
+}
@@ -0,0 +1,111 @@
+package taskfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFixture writes files in a temporary directory, their paths are relative to it
+func writeFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "taskfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestResolveVarsPrecedence(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		".env": "O=dotenv\nD=dotenv\nE=dotenv\n",
+		"Taskfile.yml": `version: '3'
+dotenv: [.env]
+env:
+  E: env
+  G: env
+vars:
+  G: global
+  I: global
+  A: '{{.G}}-a'
+includes:
+  lib:
+    taskfile: ./lib
+    vars:
+      I: include
+      N: include
+tasks:
+  root:
+    vars:
+      G: task
+      B: '{{.A}}-b'
+    cmds: [echo]
+`,
+		"lib/Taskfile.yml": `version: '3'
+vars:
+  N: included
+  M: included
+tasks:
+  compile:
+    vars:
+      M: task
+    cmds: [echo]
+`,
+	})
+	defer os.RemoveAll(dir)
+	os.Setenv("O", "environ")
+	defer os.Unsetenv("O")
+	store := NewStore()
+	root := store.Get(filepath.Join(dir, "Taskfile.yml"))
+	// The includers of a Taskfile are known once the includes are resolved
+	root.Included()
+	lib := store.Get(filepath.Join(dir, "lib", "Taskfile.yml"))
+	rootVars := root.TaskVars(root.Tasks["root"])
+	libVars := lib.TaskVars(lib.Tasks["compile"])
+	call := &Call{Task: "root", Vars: map[string]*Var{"G": {Name: "G", Value: "call"}, "C": {Name: "C", Value: "call"}}}
+	callVars := root.ResolveVars(Invocation{Task: root.Tasks["root"], Call: call, Vars: map[string]string{"B": "cli"}})
+	tests := []struct {
+		name   string
+		vars   map[string]*ResolvedVar
+		source VarSource
+		value  string
+	}{
+		{"TASK", rootVars, SourceSpecial, "root"},
+		{"TASK", libVars, SourceSpecial, "compile"},
+		// Dotenv files do not override the environment
+		{"O", rootVars, SourceEnviron, "environ"},
+		{"D", rootVars, SourceDotenv, "dotenv"},
+		{"E", rootVars, SourceEnv, "env"},
+		// A value sees the variables declared before it
+		{"A", rootVars, SourceGlobal, "global-a"},
+		{"G", rootVars, SourceTask, "task"},
+		{"B", rootVars, SourceTask, "global-a-b"},
+		{"G", libVars, SourceGlobal, "global"},
+		{"I", libVars, SourceInclude, "include"},
+		{"N", libVars, SourceIncluded, "included"},
+		{"M", libVars, SourceTask, "task"},
+		{"C", callVars, SourceCall, "call"},
+		{"G", callVars, SourceTask, "task"},
+		{"B", callVars, SourceCLI, "cli"},
+	}
+	for _, tt := range tests {
+		v, ok := tt.vars[tt.name]
+		if !ok {
+			t.Errorf("%s is not resolved", tt.name)
+			continue
+		}
+		if v.Source != tt.source || v.Value.Text != tt.value {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.name, v.Value.Text, v.Source, tt.value, tt.source)
+		}
+	}
+}
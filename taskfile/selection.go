@@ -0,0 +1,181 @@
+package taskfile
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// selection collects the ranges of the nodes enclosing a position, from the outermost to the innermost
+type selection struct {
+	src    *Source
+	line   int
+	col    int
+	ranges []Range
+}
+
+// ContainsPosition returns true if a position is inside a range, its bounds included
+func ContainsPosition(r Range, line int, col int) bool {
+	if line < r[0] || line > r[2] {
+		return false
+	}
+	return !(line == r[0] && col < r[1]) && !(line == r[2] && col > r[3])
+}
+
+func containsRange(outer Range, inner Range) bool {
+	return ContainsPosition(outer, inner[0], inner[1]) && ContainsPosition(outer, inner[2], inner[3])
+}
+
+// push keeps a range if it contains the position, returns false otherwise
+func (s *selection) push(r Range) bool {
+	if !ContainsPosition(r, s.line, s.col) {
+		return false
+	}
+	s.ranges = append(s.ranges, r)
+	return true
+}
+
+// nodeRange returns the range from a position to the end of a node
+func (s *selection) nodeRange(startLine int, startCol int, node ast.Node) Range {
+	res := Analyze(node, s.src)
+	return s.src.NewRange(startLine, startCol, res.EndLine, res.EndCol)
+}
+
+func (s *selection) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		s.entry(n)
+	case *ast.AnchorNode:
+		s.node(n.Value)
+	case *ast.TagNode:
+		s.node(n.Value)
+	case *ast.MappingNode:
+		if len(n.Values) == 0 {
+			return
+		}
+		start := n.Values[0].Key.GetToken()
+		if n.IsFlowStyle {
+			start = n.Start
+		}
+		startLine, startCol := s.src.TokenStart(start)
+		if !s.push(s.nodeRange(startLine, startCol, n)) {
+			return
+		}
+		for _, e := range n.Values {
+			s.entry(e)
+		}
+	case *ast.SequenceNode:
+		startLine, startCol := s.src.TokenStart(n.Start)
+		if !s.push(s.nodeRange(startLine, startCol, n)) {
+			return
+		}
+		for _, v := range n.Values {
+			s.item(v, n.IsFlowStyle)
+		}
+	case *ast.LiteralNode:
+		line, _ := s.src.TokenStart(n.Start)
+		s.push(s.nodeRange(line, 0, n))
+	case *ast.StringNode:
+		s.scalar(n)
+	case ast.ScalarNode:
+		s.push(s.src.TokenRange(n.GetToken()))
+	}
+}
+
+func (s *selection) entry(e *ast.MappingValueNode) {
+	if !s.push(EntryRange(e, Analyze(e, s.src), s.src)) {
+		return
+	}
+	if s.push(s.src.TokenRange(e.Key.GetToken())) {
+		return
+	}
+	s.node(e.Value)
+}
+
+// item selects an item of a sequence, the item of a block sequence starts at its dash
+func (s *selection) item(v ast.Node, flow bool) {
+	line, col := s.src.TokenStart(nodeStart(v))
+	if !flow {
+		prefix := strings.TrimRight(s.src.Line(line)[:col], " ")
+		if strings.HasSuffix(prefix, "-") {
+			col = len(prefix) - 1
+		}
+	}
+	if s.push(s.nodeRange(line, col, v)) {
+		s.node(v)
+	}
+}
+
+// scalar selects a string, its content without the quotes, then the template expression and its token
+func (s *selection) scalar(n *ast.StringNode) {
+	if !s.push(s.src.TokenRange(n.Token)) {
+		return
+	}
+	if n.Token.Type == token.SingleQuoteType || n.Token.Type == token.DoubleQuoteType {
+		s.push(s.src.ScalarRange(n))
+	}
+	for _, e := range Analyze(n, s.src).Expressions {
+		text := s.src.Line(e.Range[0])
+		start := DecodeColumn(text, e.Range[1], s.src.Encoding)
+		end := DecodeColumn(text, e.Range[3], s.src.Encoding)
+		open := strings.LastIndex(text[:start], "{{")
+		close := strings.Index(text[end:], "}}")
+		if open >= 0 && close >= 0 && !s.push(s.src.NewRange(e.Range[0], open, e.Range[0], end+close+2)) {
+			continue
+		}
+		if !s.push(e.Range) {
+			continue
+		}
+		for _, tk := range e.Tokens {
+			s.push(tk.Range)
+		}
+	}
+}
+
+// word returns the range of the letters, digits and underscores around the position
+func (s *selection) word() (Range, bool) {
+	text := s.src.Line(s.line)
+	col := DecodeColumn(text, s.col, s.src.Encoding)
+	isWord := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	start, end := col, col
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !isWord(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isWord(r) {
+			break
+		}
+		end += size
+	}
+	return s.src.NewRange(s.line, start, s.line, end), end > start
+}
+
+// SelectionRanges returns the ranges of the nodes enclosing a position, from the innermost to the outermost
+// Each range contains the previous one
+func (t *Taskfile) SelectionRanges(line int, col int) []Range {
+	s := &selection{src: t.Source, line: line, col: col, ranges: make([]Range, 0)}
+	s.node(t.Root)
+	ranges := make([]Range, 0, len(s.ranges)+1)
+	if word, ok := s.word(); ok && len(s.ranges) > 0 && containsRange(s.ranges[len(s.ranges)-1], word) {
+		ranges = append(ranges, word)
+	}
+	for i := len(s.ranges) - 1; i >= 0; i-- {
+		r := s.ranges[i]
+		if len(ranges) > 0 {
+			last := ranges[len(ranges)-1]
+			if !containsRange(r, last) || (r[0] == last[0] && r[1] == last[1] && r[2] == last[2] && r[3] == last[3]) {
+				continue
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
@@ -0,0 +1,375 @@
+package taskfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Origin tells who owns the content of a document of the store
+type Origin int
+
+const (
+	// OriginDisk is a file read from disk, it is read again when it changes
+	OriginDisk Origin = iota
+	// OriginEditor is the buffer of a document opened in the editor, the file on disk is ignored until it is closed
+	OriginEditor
+)
+
+// ErrOutOfSync is returned when the changes of a document can not be applied to the buffer known by the store
+// The document is dropped, the editor has to open it again
+type ErrOutOfSync struct {
+	Path   string
+	Reason string
+}
+
+func (e *ErrOutOfSync) Error() string {
+	return fmt.Sprintf("%s is out of sync: %s", e.Path, e.Reason)
+}
+
+// TextChange is a change of the buffer of a document, the whole buffer is replaced when the range is nil
+// The range uses the encoding of the store
+type TextChange struct {
+	Range Range
+	Text  string
+}
+
+// storeEntry is the state of a document of the store
+type storeEntry struct {
+	origin  Origin
+	version int
+	text    string
+	// revision changes with the text, a parsed Taskfile is only kept if the text did not change meanwhile
+	revision uint64
+	parsed   bool
+	// dropped is set when the buffer is out of sync with the editor, until the document is opened again
+	dropped bool
+	// taskfile is nil until the text is parsed, or when the text is not a Taskfile
+	taskfile *Taskfile
+	// snapshot is the last Taskfile parsed without syntax errors, merged into the Taskfiles recovered from an invalid text
+	// A recovered Taskfile does not replace it, its tasks would be lost by the next recovery
+	snapshot *Taskfile
+}
+
+// Store holds the Taskfiles known by the server, it is safe for concurrent use
+// The Taskfiles it returns are snapshots, they are not modified once parsed and are replaced when their document changes
+type Store struct {
+	mutex   sync.RWMutex
+	entries map[string]*storeEntry
+	// includers indexes the Taskfiles including a Taskfile by the path of the included Taskfile
+	includers map[string]map[string]bool
+	// dotenvs caches the dotenv files by path, a file is read again when it changes on disk
+	dotenvs map[string]*Dotenv
+	// generation changes with the content of any document, the tasks of the includes are resolved again
+	generation uint64
+	// encoding is the position encoding negotiated with the client, used by the ranges of the Taskfiles
+	encoding PositionEncoding
+	// globals are the variables available to every Taskfile
+	globals map[string]*Var
+}
+
+func NewStore() *Store {
+	return &Store{
+		entries:   make(map[string]*storeEntry),
+		includers: make(map[string]map[string]bool),
+		dotenvs:   make(map[string]*Dotenv),
+		encoding:  UTF16,
+		globals: map[string]*Var{
+			"OS":     {Name: "OS"},
+			"ARCH":   {Name: "ARCH"},
+			"exeExt": {Name: "exeExt"},
+		},
+	}
+}
+
+// Globals returns the variables available to every Taskfile, the map must not be modified
+func (s *Store) Globals() map[string]*Var {
+	return s.globals
+}
+
+// Encoding returns the position encoding of the ranges of the Taskfiles
+func (s *Store) Encoding() PositionEncoding {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.encoding
+}
+
+// SetEncoding changes the position encoding, the Taskfiles and the dotenv files are parsed again
+func (s *Store) SetEncoding(enc PositionEncoding) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.encoding = enc
+	for _, e := range s.entries {
+		e.revision++
+		e.parsed = false
+	}
+	s.dotenvs = make(map[string]*Dotenv)
+	s.generation++
+}
+
+// Open gives the ownership of a document to the editor with the initial content of its buffer
+func (s *Store) Open(path string, version int, text string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(path, OriginEditor, version, text)
+}
+
+// Change applies the changes of a given version to the buffer of an opened document
+// The notifications are handled in order, the changes of a document come in order. A version which
+// is not newer than the current one, or a change which does not fit the buffer, means the document
+// is out of sync: it is dropped and an ErrOutOfSync is returned
+func (s *Store) Change(path string, version int, changes []TextChange) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[path]
+	if !ok || e.origin != OriginEditor || e.dropped {
+		return fmt.Errorf("Document %s is not open", path)
+	}
+	if version <= e.version {
+		s.drop(path)
+		return &ErrOutOfSync{Path: path, Reason: fmt.Sprintf("version %d is not newer than %d", version, e.version)}
+	}
+	text, err := applyChanges(e.text, changes, s.encoding)
+	if err != nil {
+		s.drop(path)
+		return &ErrOutOfSync{Path: path, Reason: err.Error()}
+	}
+	s.set(path, OriginEditor, version, text)
+	return nil
+}
+
+// drop forgets the buffer of a document out of sync with the editor, no Taskfile is returned for it until it is opened again
+// The file on disk is not used in its place, it does not match the text of the editor. The lock must be held
+func (s *Store) drop(path string) {
+	s.set(path, OriginEditor, 0, "")
+	e := s.entries[path]
+	e.parsed, e.dropped, e.taskfile, e.snapshot = true, true, nil, nil
+}
+
+// applyChanges applies changes to a text, their ranges use the given encoding
+func applyChanges(text string, changes []TextChange, enc PositionEncoding) (string, error) {
+	for _, change := range changes {
+		if change.Range == nil {
+			text = change.Text
+			continue
+		}
+		src := NewSource(text, enc)
+		if len(change.Range) != 4 || change.Range[0] >= src.LineCount() || change.Range[2] >= src.LineCount() {
+			return "", fmt.Errorf("Invalid range %v", change.Range)
+		}
+		start := src.Offset(change.Range[0], change.Range[1])
+		end := src.Offset(change.Range[2], change.Range[3])
+		if end < start {
+			return "", fmt.Errorf("Invalid range %v", change.Range)
+		}
+		text = text[:start] + change.Text + text[end:]
+	}
+	return text, nil
+}
+
+// Text returns the buffer of a document opened in the editor, or the content of the file
+func (s *Store) Text(path string) (string, error) {
+	s.mutex.RLock()
+	e, ok := s.entries[path]
+	var text string
+	var origin Origin
+	var dropped bool
+	if ok {
+		text, origin, dropped = e.text, e.origin, e.dropped
+	}
+	s.mutex.RUnlock()
+	if dropped {
+		return "", &ErrOutOfSync{Path: path, Reason: "the document has to be opened again"}
+	}
+	if ok && origin == OriginEditor {
+		return text, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(contents), nil
+}
+
+// Close gives the ownership of a document back to the disk, it is read again when needed
+func (s *Store) Close(path string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if e, ok := s.entries[path]; ok && e.origin == OriginEditor {
+		delete(s.entries, path)
+		s.generation++
+	}
+}
+
+// Reload reads a file changed on disk, the documents opened in the editor keep their buffer
+// A file which does not exist anymore is forgotten, nil is returned. A file which can not be read is forgotten
+// too, its stale text is not used anymore, and the error is returned
+func (s *Store) Reload(path string) (*Taskfile, error) {
+	if s.IsOpen(path) {
+		return s.Get(path), nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		s.mutex.Lock()
+		if e, ok := s.entries[path]; ok && e.origin == OriginDisk {
+			delete(s.entries, path)
+			s.generation++
+		}
+		s.mutex.Unlock()
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	s.mutex.Lock()
+	// The document may have been opened while reading the file
+	if e, ok := s.entries[path]; !ok || e.origin == OriginDisk {
+		s.set(path, OriginDisk, 0, string(contents))
+	}
+	s.mutex.Unlock()
+	return s.Get(path), nil
+}
+
+// set replaces the text of a document, the lock must be held
+func (s *Store) set(path string, origin Origin, version int, text string) {
+	e, ok := s.entries[path]
+	if !ok {
+		e = &storeEntry{}
+		s.entries[path] = e
+	}
+	e.origin, e.version, e.text = origin, version, text
+	e.revision++
+	e.parsed, e.dropped = false, false
+	s.generation++
+}
+
+// Get returns the Taskfile of a path, parsed from the editor buffer or read from disk
+// Returns nil if the file does not exist or is not a Taskfile
+func (s *Store) Get(path string) *Taskfile {
+	s.mutex.RLock()
+	e, ok := s.entries[path]
+	if ok && e.parsed {
+		tf := e.taskfile
+		s.mutex.RUnlock()
+		return tf
+	}
+	var text string
+	var revision uint64
+	var snapshot *Taskfile
+	if ok {
+		text, revision, snapshot = e.text, e.revision, e.snapshot
+	}
+	enc := s.encoding
+	s.mutex.RUnlock()
+
+	if !ok {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		text = string(contents)
+	}
+	// Parsing happens without the lock, the result is dropped if the document changed meanwhile
+	tf := parseTaskfile(s, path, text, enc, snapshot)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	current, found := s.entries[path]
+	switch {
+	case s.encoding != enc:
+		return tf
+	case !ok && !found:
+		current = &storeEntry{origin: OriginDisk, text: text, revision: 1}
+		s.entries[path] = current
+	case !ok || !found || current.revision != revision:
+		return tf
+	}
+	current.parsed, current.taskfile = true, tf
+	if tf != nil && len(tf.SyntaxErrors) == 0 {
+		current.snapshot = tf
+	}
+	return tf
+}
+
+// Version returns the version of a document opened in the editor, false if it is not opened
+func (s *Store) Version(path string) (int, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if e, ok := s.entries[path]; ok && e.origin == OriginEditor {
+		return e.version, true
+	}
+	return 0, false
+}
+
+// IsOpen returns true if a document is owned by the editor
+func (s *Store) IsOpen(path string) bool {
+	_, ok := s.Version(path)
+	return ok
+}
+
+// Paths returns the paths of the documents known by the store, sorted
+func (s *Store) Paths() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	paths := make([]string, 0, len(s.entries))
+	for p := range s.entries {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// taskfiles returns the parsed Taskfiles of the store
+func (s *Store) taskfiles() map[string]*Taskfile {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	taskfiles := make(map[string]*Taskfile)
+	for p, e := range s.entries {
+		if e.taskfile != nil {
+			taskfiles[p] = e.taskfile
+		}
+	}
+	return taskfiles
+}
+
+func (s *Store) currentGeneration() uint64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.generation
+}
+
+func (s *Store) addIncluder(included string, includer string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.includers[included] == nil {
+		s.includers[included] = make(map[string]bool)
+	}
+	s.includers[included][includer] = true
+}
+
+// IncludersOf returns the paths of the Taskfiles including a Taskfile, directly or not, sorted
+func (s *Store) IncludersOf(p string) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	visited := map[string]bool{p: true}
+	includers := make([]string, 0)
+	queue := []string{p}
+	for len(queue) > 0 {
+		for includer := range s.includers[queue[0]] {
+			if !visited[includer] {
+				visited[includer] = true
+				includers = append(includers, includer)
+				queue = append(queue, includer)
+			}
+		}
+		queue = queue[1:]
+	}
+	sort.Strings(includers)
+	return includers
+}
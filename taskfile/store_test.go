@@ -0,0 +1,187 @@
+package taskfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestStoreChange(t *testing.T) {
+	const path = "/fixture/Taskfile.yml"
+	// "é" is 1 UTF-16 unit, "😀" is 2
+	text := "a: é😀b\nc: d\n"
+	tests := []struct {
+		name    string
+		enc     PositionEncoding
+		changes []TextChange
+		want    string
+	}{
+		{"after a surrogate pair", UTF16, []TextChange{{Range: Range{0, 6, 0, 7}, Text: "x"}}, "a: é😀x\nc: d\n"},
+		{"over a surrogate pair", UTF16, []TextChange{{Range: Range{0, 3, 0, 6}, Text: ""}}, "a: b\nc: d\n"},
+		{"code points", UTF32, []TextChange{{Range: Range{0, 4, 0, 5}, Text: "🙂"}}, "a: é🙂b\nc: d\n"},
+		{"bytes", UTF8, []TextChange{{Range: Range{0, 3, 0, 5}, Text: "e"}}, "a: e😀b\nc: d\n"},
+		{"across lines", UTF16, []TextChange{{Range: Range{0, 6, 1, 1}, Text: "\ne"}}, "a: é😀\ne: d\n"},
+		{"in sequence", UTF16, []TextChange{
+			{Range: Range{1, 0, 1, 0}, Text: "😀"},
+			{Range: Range{1, 2, 1, 3}, Text: "f"},
+		}, "a: é😀b\n😀f: d\n"},
+		{"whole text", UTF16, []TextChange{{Text: "x: 😀"}, {Range: Range{0, 3, 0, 5}, Text: "y"}}, "x: y"},
+	}
+	for _, tt := range tests {
+		store := NewStore()
+		store.SetEncoding(tt.enc)
+		store.Open(path, 1, text)
+		if err := store.Change(path, 2, tt.changes); err != nil {
+			t.Errorf("%s: Change failed: %s", tt.name, err)
+			continue
+		}
+		if got, _ := store.Text(path); got != tt.want {
+			t.Errorf("%s: Text = %q, want %q", tt.name, got, tt.want)
+		}
+		if version, _ := store.Version(path); version != 2 {
+			t.Errorf("%s: Version = %d, want 2", tt.name, version)
+		}
+	}
+}
+
+func TestStoreOutOfSync(t *testing.T) {
+	const path = "/fixture/Taskfile.yml"
+	const text = "version: '3'\ntasks:\n  a:\n    cmds: [echo a]\n"
+	tests := []struct {
+		name    string
+		version int
+		changes []TextChange
+	}{
+		{"same version", 3, []TextChange{{Text: text}}},
+		{"older version", 2, []TextChange{{Text: text}}},
+		{"line out of the text", 4, []TextChange{{Range: Range{9, 0, 9, 0}, Text: "x"}}},
+		{"reversed range", 4, []TextChange{{Range: Range{1, 2, 0, 0}, Text: "x"}}},
+	}
+	for _, tt := range tests {
+		store := NewStore()
+		store.Open(path, 3, text)
+		err := store.Change(path, tt.version, tt.changes)
+		if _, ok := err.(*ErrOutOfSync); !ok {
+			t.Errorf("%s: Change = %v, want ErrOutOfSync", tt.name, err)
+			continue
+		}
+		// The buffer is dropped until the document is opened again, the file on disk does not replace it
+		if tf := store.Get(path); tf != nil {
+			t.Errorf("%s: a Taskfile is returned for a dropped document", tt.name)
+		}
+		if _, err := store.Text(path); err == nil {
+			t.Errorf("%s: Text returned the text of a dropped document", tt.name)
+		}
+		if err := store.Change(path, 5, []TextChange{{Text: text}}); err == nil {
+			t.Errorf("%s: a dropped document accepted a change", tt.name)
+		}
+		store.Open(path, 6, text)
+		if tf := store.Get(path); tf == nil || tf.Tasks["a"] == nil {
+			t.Errorf("%s: the document opened again is not parsed", tt.name)
+		}
+	}
+}
+
+func TestStoreOwnership(t *testing.T) {
+	const disk = "version: '3'\ntasks:\n  disk:\n    cmds: [echo]\n"
+	const editor = "version: '3'\ntasks:\n  editor:\n    cmds: [echo]\n"
+	dir := writeFixture(t, map[string]string{"Taskfile.yml": disk})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Taskfile.yml")
+	store := NewStore()
+	taskOf := func() string {
+		tf := store.Get(path)
+		if tf == nil {
+			return ""
+		}
+		for name := range tf.Tasks {
+			return name
+		}
+		return ""
+	}
+	if got := taskOf(); got != "disk" {
+		t.Errorf("before opening: task %q, want disk", got)
+	}
+	store.Open(path, 1, editor)
+	if got := taskOf(); got != "editor" {
+		t.Errorf("opened: task %q, want editor", got)
+	}
+	// The buffer of the editor wins over the changes on disk
+	if err := ioutil.WriteFile(path, []byte(disk), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if tf, err := store.Reload(path); err != nil || tf == nil || tf.Tasks["editor"] == nil {
+		t.Errorf("opened: Reload = %v, %v, want the buffer of the editor", tf, err)
+	}
+	store.Close(path)
+	if store.IsOpen(path) {
+		t.Error("closed: the document is still open")
+	}
+	if got := taskOf(); got != "disk" {
+		t.Errorf("closed: task %q, want disk", got)
+	}
+	if got, _ := store.Text(path); got != disk {
+		t.Errorf("closed: Text = %q, want the file on disk", got)
+	}
+	known := func() bool {
+		for _, p := range store.Paths() {
+			if p == path {
+				return true
+			}
+		}
+		return false
+	}
+	// A file which can not be read is forgotten, the error is returned
+	os.Remove(path)
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if tf, err := store.Reload(path); err == nil || tf != nil || known() {
+		t.Errorf("unreadable: Reload = %v, %v, want an error and the file forgotten", tf, err)
+	}
+	// A deleted file is forgotten
+	os.Remove(path)
+	ioutil.WriteFile(path, []byte(disk), 0644)
+	store.Get(path)
+	os.Remove(path)
+	if tf, err := store.Reload(path); err != nil || tf != nil || known() {
+		t.Errorf("deleted: Reload = %v, %v, want nothing and the file forgotten", tf, err)
+	}
+}
+
+// TestStoreConcurrency is meant to be run with -race, the Taskfiles are parsed while the buffer changes
+func TestStoreConcurrency(t *testing.T) {
+	const path = "/fixture/Taskfile.yml"
+	store := NewStore()
+	store.Open(path, 1, "version: '3'\ntasks:\n")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if tf := store.Get(path); tf != nil {
+					tf.Dependents("a")
+				}
+				store.Text(path)
+			}
+		}()
+	}
+	const last = 200
+	for version := 2; version <= last; version++ {
+		task := fmt.Sprintf("  t%d:\n    cmds: [echo]\n", version)
+		if err := store.Change(path, version, []TextChange{{Range: Range{2, 0, 2, 0}, Text: task}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if tf := store.Get(path); tf == nil || len(tf.Tasks) != last-1 {
+		t.Error("the last version is not parsed")
+	}
+	if version, _ := store.Version(path); version != last {
+		t.Errorf("Version = %d, want %d", version, last)
+	}
+}
@@ -0,0 +1,132 @@
+package taskfile
+
+type SymbolKind int
+
+const (
+	SymbolTask SymbolKind = iota
+	SymbolVar
+)
+
+// Symbol is a task or a variable of a Taskfile
+type Symbol struct {
+	Kind SymbolKind
+	Name string
+	// Task is the task declaring a variable, empty for the variables of the Taskfile
+	Task string
+}
+
+// VarScope returns the task declaring a variable used in a task, empty if the variable belongs to the Taskfile
+// Variables are declared by the task itself or passed by its callers
+func (t *Taskfile) VarScope(task *Task, name string) string {
+	if task == nil {
+		return ""
+	}
+	if _, ok := task.Vars[name]; ok || t.IsPassedVar(task, name) {
+		return task.Name
+	}
+	return ""
+}
+
+// SymbolAt returns the task or the variable at a position
+func (t *Taskfile) SymbolAt(line int, col int) (Symbol, bool) {
+	for name, v := range t.Vars {
+		if IsInRange(line, col, v.NameRange) {
+			return Symbol{Kind: SymbolVar, Name: name}, true
+		}
+	}
+	if sym, ok := fieldAt(t.Expressions, line, col); ok {
+		return sym, true
+	}
+	for _, task := range t.Tasks {
+		if IsInRange(line, col, task.NameRange) {
+			return Symbol{Kind: SymbolTask, Name: task.Name}, true
+		}
+		for name, v := range task.Vars {
+			if IsInRange(line, col, v.NameRange) {
+				return Symbol{Kind: SymbolVar, Name: name, Task: task.Name}, true
+			}
+		}
+		for _, c := range task.Calls() {
+			if IsInRange(line, col, c.Range) {
+				return Symbol{Kind: SymbolTask, Name: c.Task}, true
+			}
+			// Variables passed by a call belong to the called task
+			for name, v := range c.Vars {
+				if IsInRange(line, col, v.NameRange) {
+					return Symbol{Kind: SymbolVar, Name: name, Task: c.Task}, true
+				}
+			}
+		}
+		if sym, ok := fieldAt(task.Expressions, line, col); ok {
+			sym.Task = t.VarScope(task, sym.Name)
+			return sym, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// fieldAt returns the variable of the field at a position
+func fieldAt(exprs []Expr, line int, col int) (Symbol, bool) {
+	for _, e := range exprs {
+		for _, tk := range e.Tokens {
+			if name := tk.FieldName(); name != "" && IsInRange(line, col, tk.Range) {
+				return Symbol{Kind: SymbolVar, Name: name}, true
+			}
+		}
+	}
+	return Symbol{}, false
+}
+
+// fieldRanges returns the ranges of the fields of a variable
+func fieldRanges(exprs []Expr, name string) []Range {
+	ranges := make([]Range, 0)
+	for _, e := range exprs {
+		for _, tk := range e.Tokens {
+			if tk.FieldName() == name {
+				ranges = append(ranges, tk.Range)
+			}
+		}
+	}
+	return ranges
+}
+
+// Occurrences returns the ranges where a symbol is declared and where it is used
+func (t *Taskfile) Occurrences(sym Symbol) ([]Range, []Range) {
+	declarations := make([]Range, 0)
+	usages := make([]Range, 0)
+	if sym.Kind == SymbolTask {
+		if task, ok := t.Tasks[sym.Name]; ok {
+			declarations = append(declarations, task.NameRange)
+		}
+		for _, task := range t.Tasks {
+			for _, c := range task.Calls() {
+				if c.Task == sym.Name {
+					usages = append(usages, c.Range)
+				}
+			}
+		}
+		return declarations, usages
+	}
+	if sym.Task == "" {
+		if v, ok := t.Vars[sym.Name]; ok {
+			declarations = append(declarations, v.NameRange)
+		}
+		usages = append(usages, fieldRanges(t.Expressions, sym.Name)...)
+	}
+	for _, task := range t.Tasks {
+		if task.Name == sym.Task {
+			if v, ok := task.Vars[sym.Name]; ok {
+				declarations = append(declarations, v.NameRange)
+			}
+		}
+		for _, c := range task.Calls() {
+			if v, ok := c.Vars[sym.Name]; ok && c.Task == sym.Task {
+				declarations = append(declarations, v.NameRange)
+			}
+		}
+		if t.VarScope(task, sym.Name) == sym.Task {
+			usages = append(usages, fieldRanges(task.Expressions, sym.Name)...)
+		}
+	}
+	return declarations, usages
+}
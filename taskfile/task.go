@@ -9,8 +9,71 @@ import (
 type Task struct {
 	Name        string          `json:"name"`
 	Range       Range           `json:"range"`
+	NameRange   Range           `json:"nameRange"`
 	Vars        map[string]*Var `json:"vars"`
+	Env         map[string]*Var `json:"env"`
 	Expressions []Expr          `json:"expressions"`
+	Desc        *String         `json:"desc,omitempty"`
+	Summary     *String         `json:"summary,omitempty"`
+	// Properties of the task spanning multiple lines
+	Blocks []Block `json:"blocks"`
+	// Ranges of the scalars spanning multiple lines
+	Scalars []Range `json:"scalars"`
+	// Ranges of the keys of the properties, indexed by name
+	Keys map[string]Range `json:"keys"`
+	// CmdsString is true when the cmds are a single string instead of a list
+	CmdsString bool `json:"cmdsString"`
+	// CmdsFlow is true when the cmds are a flow sequence `[a, b]`
+	CmdsFlow bool `json:"cmdsFlow"`
+	// Paths of the dir, the dotenv files, the sources and the generated files
+	Paths []Path `json:"paths"`
+	// Typed properties of the task, nil or empty when they are not set
+	Label         *String        `json:"label,omitempty"`
+	Aliases       []String       `json:"aliases,omitempty"`
+	Deps          []Dep          `json:"deps,omitempty"`
+	Commands      []Command      `json:"commands,omitempty"`
+	Sources       []String       `json:"sources,omitempty"`
+	Generates     []String       `json:"generates,omitempty"`
+	Status        []String       `json:"status,omitempty"`
+	Preconditions []Precondition `json:"preconditions,omitempty"`
+	Requires      []String       `json:"requires,omitempty"`
+	Dir           *String        `json:"dir,omitempty"`
+	Method        *String        `json:"method,omitempty"`
+	Prefix        *String        `json:"prefix,omitempty"`
+	Run           *String        `json:"run,omitempty"`
+	Platforms     []String       `json:"platforms,omitempty"`
+	Dotenv        []String       `json:"dotenv,omitempty"`
+	Set           []String       `json:"set,omitempty"`
+	Shopt         []String       `json:"shopt,omitempty"`
+	Silent        *Bool          `json:"silent,omitempty"`
+	IgnoreError   *Bool          `json:"ignoreError,omitempty"`
+	Internal      *Bool          `json:"internal,omitempty"`
+	Interactive   *Bool          `json:"interactive,omitempty"`
+}
+
+// Block is a mapping entry spanning multiple lines
+type Block struct {
+	Name  string `json:"name"`
+	Range Range  `json:"range"`
+}
+
+// EntryRange returns the range of a mapping entry, from its key to the end of its value
+func EntryRange(node *ast.MappingValueNode, res *Result, src *Source) Range {
+	startLine, startCol := src.TokenStart(node.Key.GetToken())
+	return src.NewRange(startLine, startCol, res.EndLine, res.EndCol)
+}
+
+// ExtractBlocks returns the entries of a mapping spanning multiple lines
+func ExtractBlocks(node ast.Node, src *Source) []Block {
+	blocks := make([]Block, 0)
+	entries, _ := mappingEntries(node)
+	for _, e := range entries {
+		r := EntryRange(e, Analyze(e, src), src)
+		if r[2] > r[0] {
+			blocks = append(blocks, Block{Name: ScalarValue(e.Key), Range: r})
+		}
+	}
+	return blocks
 }
 
 func (t *Task) ExpressionAtPosition(line int, col int) *Expr {
@@ -22,7 +85,19 @@ func (t *Task) ExpressionAtPosition(line int, col int) *Expr {
 	return nil
 }
 
-func GetTasks(node *ast.MappingValueNode) (map[string]*Task, error) {
+// CommandAtPosition returns the shell command of the task at a position, nil if the position is not in a shell command
+func (t *Task) CommandAtPosition(line int, col int) *String {
+	for _, c := range t.Commands {
+		for cmd := &c; cmd != nil; cmd = cmd.Defer {
+			if cmd.Cmd != nil && IsInRange(line, col, cmd.Cmd.Range) {
+				return cmd.Cmd
+			}
+		}
+	}
+	return nil
+}
+
+func GetTasks(node *ast.MappingValueNode, src *Source) (map[string]*Task, error) {
 	sn, ok := node.Key.(*ast.StringNode)
 	if !ok {
 		return nil, fmt.Errorf("OOPS")
@@ -30,47 +105,60 @@ func GetTasks(node *ast.MappingValueNode) (map[string]*Task, error) {
 	if sn.Value == "tasks" {
 		switch tasksNode := node.Value.(type) {
 		case *ast.MappingValueNode:
-			key, val := ExtractTaskFromMappingValueNode(tasksNode)
+			key, val := ExtractTaskFromMappingValueNode(tasksNode, src)
 			tasks := make(map[string]*Task)
 			tasks[key] = val
 			return tasks, nil
 		case *ast.MappingNode:
-			return ExtractTasksFromMappingNode(tasksNode)
+			return ExtractTasksFromMappingNode(tasksNode, src)
 		}
 	}
 	return nil, nil
 }
 
-func ExtractTasksFromMappingNode(node *ast.MappingNode) (map[string]*Task, error) {
+func ExtractTasksFromMappingNode(node *ast.MappingNode, src *Source) (map[string]*Task, error) {
 	tasks := make(map[string]*Task)
 	for _, v := range node.Values {
-		key, val := ExtractTaskFromMappingValueNode(v)
+		key, val := ExtractTaskFromMappingValueNode(v, src)
 		tasks[key] = val
 	}
 	return tasks, nil
 }
 
-func ExtractTaskFromMappingValueNode(node *ast.MappingValueNode) (string, *Task) {
+func ExtractTaskFromMappingValueNode(node *ast.MappingValueNode, src *Source) (string, *Task) {
 	name := node.Key.(*ast.StringNode).Value
-	res := Analyze(node)
-	var r Range = []int{
-		node.Key.GetToken().Position.Line - 1,
-		node.Key.GetToken().Position.Column - 1,
-		res.LastToken.Position.Line - 1,
-		res.LastToken.Position.Column + len(res.LastToken.Value) - 1,
+	res := Analyze(node, src)
+	task := &Task{
+		Name:        name,
+		Range:       EntryRange(node, res, src),
+		Expressions: res.Expressions,
+		Blocks:      ExtractBlocks(node.Value, src),
+		Scalars:     res.Blocks,
+		NameRange:   src.TokenRange(node.Key.GetToken()),
+		Keys:        make(map[string]Range),
+		Paths:       ExtractTaskPaths(node.Value, src),
+	}
+	props, ok := mappingEntries(node.Value)
+	extractTaskProperties(task, props, src)
+	if !ok {
+		// A task can be written as its commands alone
+		task.Commands = ExtractCommands(node.Value, src)
+	}
+	for _, p := range props {
+		task.Keys[ScalarValue(p.Key)] = src.TokenRange(p.Key.GetToken())
 	}
-	task := &Task{Name: name, Range: r, Expressions: res.Expressions}
 	varsNode, ok := node.Value.(*ast.MappingNode)
 	if ok {
-		vars, _ := ExtractTaskVarsFromMappingNode(varsNode)
+		vars, _ := ExtractTaskVarsFromMappingNode(varsNode, src)
 		task.Vars = vars
+		task.Env = ExtractTaskEnvFromMappingNode(varsNode, src)
 	}
 	return name, task
 }
 
-func ExtractTaskVarsFromMappingNode(node *ast.MappingNode) (map[string]*Var, error) {
+func ExtractTaskVarsFromMappingNode(node *ast.MappingNode, src *Source) (map[string]*Var, error) {
 	for _, v := range node.Values {
-		vars, err := GetVars(v)
+		vars, err := GetVars(v, src)
 		if err != nil {
 			return nil, err
 		}
@@ -80,3 +168,14 @@ func ExtractTaskVarsFromMappingNode(node *ast.MappingNode) (map[string]*Var, err
 	}
 	return nil, nil
 }
+
+// ExtractTaskEnvFromMappingNode returns the environment variables of a task
+func ExtractTaskEnvFromMappingNode(node *ast.MappingNode, src *Source) map[string]*Var {
+	for _, v := range node.Values {
+		env, err := GetEnv(v, src)
+		if err == nil && env != nil {
+			return env
+		}
+	}
+	return nil
+}
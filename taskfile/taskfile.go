@@ -2,32 +2,68 @@ package taskfile
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
-	"regexp"
+	"sync"
 
 	"github.com/goccy/go-yaml/ast"
-	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/lexer"
 	"github.com/goccy/go-yaml/token"
 )
 
 type Range []int
 
-var Vars map[string]*Var
-
-func init() {
-	Vars = make(map[string]*Var)
-	Vars["OS"] = &Var{Name: "OS"}
-	Vars["ARCH"] = &Var{Name: "ARCH"}
-	Vars["exeExt"] = &Var{Name: "exeExt"}
+// SpecialVars are the variables set by Task itself
+var SpecialVars = map[string]bool{
+	"TASK": true, "ALIAS": true, "MATCH": true, "TASK_EXE": true, "TASK_VERSION": true, "TASK_DIR": true,
+	"ROOT_TASKFILE": true, "ROOT_DIR": true, "TASKFILE": true, "TASKFILE_DIR": true, "USER_WORKING_DIR": true,
+	"CHECKSUM": true, "TIMESTAMP": true, "ITEM": true, "EXIT_CODE": true,
+	"CLI_ARGS": true, "CLI_ARGS_LIST": true, "CLI_FORCE": true, "CLI_SILENT": true, "CLI_VERBOSE": true,
+	"CLI_OFFLINE": true, "CLI_ASSUME_YES": true,
 }
 
 type Taskfile struct {
-	Path     string           `json:"path"`
-	Tasks    map[string]*Task `json:"tasks"`
-	Vars     map[string]*Var  `json:"vars"`
-	Stale    bool             `json:"-"`
-	Contents string           `json:"-"`
+	Path    string           `json:"path"`
+	Tasks   map[string]*Task `json:"tasks"`
+	Vars    map[string]*Var  `json:"vars"`
+	Env     map[string]*Var  `json:"env"`
+	Version string           `json:"version"`
+	Source  *Source          `json:"-"`
+	// Top level entries spanning multiple lines
+	Blocks []Block `json:"blocks"`
+	// Ranges of the scalars spanning multiple lines outside of the tasks
+	Scalars []Range `json:"scalars"`
+	// Ranges of the comments
+	Comments []Range `json:"comments"`
+	// Expressions found outside of the tasks
+	Expressions []Expr `json:"expressions"`
+	// Ranges of the top level entries, indexed by key
+	Entries map[string]Range `json:"entries"`
+	// Paths of the includes and of the dotenv files
+	Paths []Path `json:"paths"`
+	// Includes indexed by namespace
+	Includes map[string]*Include `json:"includes"`
+	// Root is the mapping at the top of the document
+	Root ast.Node `json:"-"`
+	// Typed settings of the Taskfile, nil or empty when they are not set
+	// Output is only set when it is the name of a mode, not the options of the group mode
+	Output   *String  `json:"output,omitempty"`
+	Method   *String  `json:"method,omitempty"`
+	Run      *String  `json:"run,omitempty"`
+	Interval *String  `json:"interval,omitempty"`
+	Silent   *Bool    `json:"silent,omitempty"`
+	Dotenv   []String `json:"dotenv,omitempty"`
+	Set      []String `json:"set,omitempty"`
+	Shopt    []String `json:"shopt,omitempty"`
+	// Expansions is the number of times the variables are expanded, by the Taskfiles of version 2
+	Expansions *String `json:"expansions,omitempty"`
+	// SyntaxErrors are the lines ignored because they are not valid YAML
+	SyntaxErrors []SyntaxError `json:"syntaxErrors,omitempty"`
+	// store is the Store the Taskfile was parsed by, its includes and dotenv files are loaded from it
+	store *Store
+	// Tasks and problems of the includes, resolved on demand for a generation of the store
+	includedMutex      sync.Mutex
+	includedGeneration uint64
+	included           []IncludedTask
+	includeProblems    []Problem
 }
 
 func IsInRange(line int, col int, r Range) bool {
@@ -43,18 +79,45 @@ func (t *Taskfile) TaskAtPosition(line int, col int) *Task {
 	return nil
 }
 
-func Parse(doc *ast.Document) (*Taskfile, error) {
+func Parse(doc *ast.Document, src *Source) (*Taskfile, error) {
 	m, ok := doc.Body.(*ast.MappingNode)
 	if !ok {
 		return nil, fmt.Errorf("OOPS")
 	}
-	taskfile := &Taskfile{Stale: false}
+	taskfile := &Taskfile{
+		Root:        m,
+		Source:      src,
+		Blocks:      ExtractBlocks(m, src),
+		Scalars:     make([]Range, 0),
+		Comments:    ExtractComments(src),
+		Expressions: make([]Expr, 0),
+		Entries:     make(map[string]Range),
+		Paths:       make([]Path, 0),
+		Includes:    make(map[string]*Include),
+	}
 	for _, v := range m.Values {
-		tasks, err := GetTasks(v)
+		key := ScalarValue(v.Key)
+		taskfile.Entries[key] = EntryRange(v, Analyze(v, src), src)
+		extractTaskfileProperty(taskfile, key, v.Value, src)
+		switch key {
+		case "version":
+			taskfile.Version = ScalarValue(v.Value)
+		case "includes":
+			taskfile.Paths = append(taskfile.Paths, ExtractIncludePaths(v.Value, src)...)
+			taskfile.Includes = ExtractIncludes(v.Value, src)
+		case "dotenv":
+			taskfile.Paths = append(taskfile.Paths, listPaths(PathDotenv, v.Value, src)...)
+		}
+		if key != "tasks" {
+			res := Analyze(v, src)
+			taskfile.Scalars = append(taskfile.Scalars, res.Blocks...)
+			taskfile.Expressions = append(taskfile.Expressions, res.Expressions...)
+		}
+		tasks, err := GetTasks(v, src)
 		if err != nil {
 			return nil, err
 		}
-		vars, err := GetVars(v)
+		vars, err := GetVars(v, src)
 		if err != nil {
 			return nil, err
 		}
@@ -64,148 +127,206 @@ func Parse(doc *ast.Document) (*Taskfile, error) {
 		if vars != nil {
 			taskfile.Vars = vars
 		}
+		env, err := GetEnv(v, src)
+		if err != nil {
+			return nil, err
+		}
+		if env != nil {
+			taskfile.Env = env
+		}
 	}
 	return taskfile, nil
 }
 
-func Invalidate(p string, contents string) {
-	tf, ok := Taskfiles[p]
-	if !ok {
-		Taskfiles[p] = &Taskfile{
-			Stale:    true,
-			Contents: contents,
+// ExtractComments returns the ranges of the comments of a source
+func ExtractComments(src *Source) []Range {
+	comments := make([]Range, 0)
+	for _, tk := range lexer.Tokenize(src.Text) {
+		if tk.Type != token.CommentType {
+			continue
 		}
-	} else {
-		tf.Stale = true
-		tf.Contents = contents
+		line, col := src.TokenStart(tk)
+		comments = append(comments, src.NewRange(line, col, line, len(src.Line(line))))
 	}
+	return comments
 }
 
-// PreloadWithBytes will parse a yaml file and extract
+// parseTaskfile parses a yaml file and extracts
 // the Taskfile specific information like tasks, variables and expressions
-// This will ignore parsing errors
-func PreloadWithBytes(path string, contents []byte) *Taskfile {
-	f, err := parser.ParseBytes(contents, parser.ParseComments)
+// The lines which are not valid YAML are ignored, the tasks and variables of the snapshot fill the gaps
+func parseTaskfile(store *Store, path string, contents string, enc PositionEncoding, snapshot *Taskfile) *Taskfile {
+	f, syntaxErrors, err := parseTolerant(contents)
 	if err != nil {
-		// TODO: Try partial parsing and keep valid things in the tree
 		return nil
 	}
-	tf, err := Parse(f.Docs[0])
-	tf.Path = path
-	tf.Stale = false
+	tf, err := Parse(f.Docs[0], NewSource(contents, enc))
 	if err != nil {
-		// TODO: Try partial parsing and keep valid things in the tree
 		return nil
 	}
-	Taskfiles[path] = tf
-	return tf
-}
-
-func Preload(path string) (*Taskfile, error) {
-	bytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	return PreloadWithBytes(path, bytes), nil
-}
-
-func GetParsedTaskfile(path string) *Taskfile {
-	tf, ok := Taskfiles[path]
-	if !ok {
-		info, err := os.Stat(path)
-		if os.IsNotExist(err) || info.IsDir() {
-			return nil
-		}
-		tf, err = Preload(path)
-		if err != nil {
-			return nil
-		}
-		return tf
-	}
-	if !tf.Stale {
-		return tf
+	tf.Path = path
+	tf.SyntaxErrors = syntaxErrors
+	tf.store = store
+	if len(syntaxErrors) > 0 {
+		tf.mergeSnapshot(snapshot)
 	}
-	return PreloadWithBytes(path, []byte(tf.Contents))
+	return tf
 }
 
 type Expr struct {
-	Range Range
-	Value string
+	Range  Range
+	Value  string
+	Tokens []TemplateToken
+	// Nodes are the operands of the expression, nil when the template of the string is not valid
+	Nodes []TemplateNode
 }
 
 type ExprInString struct {
 	Indices [2]int
 	Value   string
+	Tokens  []TemplateToken
+	Nodes   []TemplateNode
 }
 
 type Result struct {
 	LastToken   *token.Token
 	Expressions []Expr
+	// Ranges of the scalars spanning multiple lines
+	Blocks []Range
+	// Line and byte column where the analyzed node ends
+	EndLine int
+	EndCol  int
 }
 
+// merge appends the findings of a child node, the child is expected to be the last node analyzed
+func (r *Result) merge(child *Result) {
+	if child == nil {
+		return
+	}
+	r.Expressions = append(r.Expressions, child.Expressions...)
+	r.Blocks = append(r.Blocks, child.Blocks...)
+	if child.LastToken != nil {
+		r.LastToken = child.LastToken
+		r.EndLine = child.EndLine
+		r.EndCol = child.EndCol
+	}
+}
+
+// GetAllExpr returns the expressions found in a string
+// Indices are byte offsets of the content of the expression
 func GetAllExpr(src string) []ExprInString {
-	r := regexp.MustCompile(`{{(.*?)}}`)
-	f := r.FindAllSubmatchIndex([]byte(src), 12)
 	items := make([]ExprInString, 0)
-	ru := []rune(src)
-	for _, i := range f {
-		expr := ExprInString{Value: string(ru[i[2]:i[3]]), Indices: [2]int{i[2], i[3]}}
+	// Blocks span several actions, the whole string is parsed
+	nodes, err := ParseTemplate(src)
+	for _, a := range FindActions(src) {
+		expr := ExprInString{Value: a.Value, Indices: [2]int{a.Start, a.End}, Tokens: a.Tokens}
+		if err == nil {
+			expr.Nodes = make([]TemplateNode, 0)
+		}
+		for _, n := range nodes {
+			if n.Start >= a.Start && n.End <= a.End {
+				expr.Nodes = append(expr.Nodes, n)
+			}
+		}
 		items = append(items, expr)
 	}
 	return items
 }
 
-func Analyze(node ast.Node) *Result {
+// scalarExpressions returns the expressions of the value of a scalar, their ranges are mapped to the document
+func scalarExpressions(value string, m *ScalarMap, src *Source) []Expr {
+	exprs := make([]Expr, 0)
+	for _, exp := range GetAllExpr(value) {
+		for i, tk := range exp.Tokens {
+			exp.Tokens[i].Range = m.Range(src, tk.Start, tk.End)
+		}
+		for i, n := range exp.Nodes {
+			exp.Nodes[i].Range = m.Range(src, n.Start, n.End)
+		}
+		rang := m.Range(src, exp.Indices[0], exp.Indices[1])
+		exprs = append(exprs, Expr{Value: exp.Value, Range: rang, Tokens: exp.Tokens, Nodes: exp.Nodes})
+	}
+	return exprs
+}
+
+// Analyze walks a node and collects the expressions and the end position of the node
+func Analyze(node ast.Node, src *Source) *Result {
+	res := &Result{Expressions: make([]Expr, 0), Blocks: make([]Range, 0)}
 	switch n := node.(type) {
+	case nil:
+		return res
 	case *ast.MappingValueNode:
-		return Analyze(n.Value)
+		res.merge(Analyze(n.Key, src))
+		res.merge(Analyze(n.Value, src))
+	case *ast.AnchorNode:
+		res.merge(Analyze(n.Value, src))
+	case *ast.TagNode:
+		res.merge(Analyze(n.Value, src))
+	case *ast.AliasNode:
+		res.merge(Analyze(n.Value, src))
+	case *ast.LiteralNode:
+		line, _ := src.TokenStart(n.Start)
+		end := src.BlockScalarEnd(line)
+		res.LastToken = n.Start
+		res.EndLine = end
+		res.EndCol = len(src.Line(end))
+		if end > line {
+			res.Blocks = append(res.Blocks, src.NewRange(line, 0, end, res.EndCol))
+		}
+		if n.Value != nil {
+			// The content starts on the line following the header
+			indent := src.blockIndent(line, end)
+			m := src.MapScalar(n.Value.Value, StyleBlock, line+1, indent, indent)
+			res.Expressions = append(res.Expressions, scalarExpressions(n.Value.Value, m, src)...)
+		}
 	case ast.ScalarNode:
-		expressions := make([]Expr, 0)
 		if sn, ok := n.(*ast.StringNode); ok {
-			exps := GetAllExpr(sn.Value)
-			for _, exp := range exps {
-				rang := []int{
-					sn.Token.Position.Line - 1,
-					sn.Token.Position.Column + exp.Indices[0] - 1,
-					sn.Token.Position.Line - 1,
-					sn.Token.Position.Column + exp.Indices[1] - 1,
-				}
-				expressions = append(expressions, Expr{Value: exp.Value, Range: rang})
+			line, col := src.TokenStart(sn.Token)
+			style := scalarStyle(sn.Token)
+			// Skip the opening quote
+			if style == StyleSingleQuoted || style == StyleDoubleQuoted {
+				col++
 			}
+			m := src.MapScalar(sn.Value, style, line, col, 0)
+			res.Expressions = append(res.Expressions, scalarExpressions(sn.Value, m, src)...)
 		}
-		return &Result{
-			LastToken:   n.GetToken(),
-			Expressions: expressions,
-		}
+		res.LastToken = n.GetToken()
+		res.EndLine, res.EndCol = src.TokenEnd(res.LastToken)
 	case *ast.SequenceNode:
-		var t *token.Token
-		expressions := make([]Expr, 0)
-		for i, v := range n.Values {
-			a := Analyze(v)
-			expressions = append(expressions, a.Expressions...)
-			if i == (len(n.Values) - 1) {
-				t = a.LastToken
-			}
+		for _, v := range n.Values {
+			res.merge(Analyze(v, src))
 		}
-		return &Result{
-			LastToken:   t,
-			Expressions: expressions,
+		if n.IsFlowStyle && n.End != nil {
+			res.LastToken = n.End
+			res.EndLine, res.EndCol = src.TokenEnd(n.End)
 		}
 	case *ast.MappingNode:
-		var t *token.Token
-		expressions := make([]Expr, 0)
-		for i, v := range n.Values {
-			a := Analyze(v)
-			expressions = append(expressions, a.Expressions...)
-			if i == (len(n.Values) - 1) {
-				t = a.LastToken
-			}
+		for _, v := range n.Values {
+			res.merge(Analyze(v, src))
 		}
-		return &Result{
-			LastToken:   t,
-			Expressions: expressions,
+		if n.IsFlowStyle && n.End != nil {
+			res.LastToken = n.End
+			res.EndLine, res.EndCol = src.TokenEnd(n.End)
 		}
-	default:
-		return nil
 	}
+	return res
+}
+
+// CommentBlocks returns the ranges of consecutive full line comments
+func (t *Taskfile) CommentBlocks() []Range {
+	blocks := make([]Range, 0)
+	var current Range
+	for _, c := range t.Comments {
+		// Skip the comments following a value
+		if c[1] != Indentation(t.Source.Line(c[0])) {
+			continue
+		}
+		if current != nil && current[2] == c[0]-1 {
+			current = []int{current[0], current[1], c[2], c[3]}
+			blocks[len(blocks)-1] = current
+			continue
+		}
+		current = c
+		blocks = append(blocks, current)
+	}
+	return blocks
 }
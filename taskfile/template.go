@@ -0,0 +1,231 @@
+package taskfile
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TemplateTokenKind int
+
+const (
+	// A field of the data, `.VAR` or `.A.B`
+	TokenField TemplateTokenKind = iota
+	// A template variable, `$name`
+	TokenVariable
+	// A function call, `printf`
+	TokenFunction
+	// A keyword of the template language, `if`, `range`, `end`...
+	TokenKeyword
+	// The pipe between commands, `|`
+	TokenPipe
+	// Declarations, assignments and parenthesis, `:=`, `=`, `(`
+	TokenOperator
+	TokenString
+	TokenNumber
+	TokenComment
+)
+
+// TemplateLiterals are the keywords which are values, an action can hold them alone
+var TemplateLiterals = map[string]bool{"true": true, "false": true, "nil": true}
+
+// TemplateKeywords are the identifiers reserved by text/template
+var TemplateKeywords = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true,
+	"define": true, "template": true, "block": true, "break": true, "continue": true,
+	"true": true, "false": true, "nil": true,
+}
+
+type TemplateToken struct {
+	Kind  TemplateTokenKind `json:"kind"`
+	Value string            `json:"value"`
+	// Byte offsets of the token in the string holding the action
+	Start int `json:"-"`
+	End   int `json:"-"`
+	// Range of the token in the document, filled when analyzing a Taskfile
+	Range Range `json:"range"`
+}
+
+// FieldName returns the name of the variable of a field, `.A.B` gives `A`
+// An empty string is returned for the dot alone and the other kinds of tokens
+func (tk TemplateToken) FieldName() string {
+	if tk.Kind != TokenField {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(tk.Value, "."), ".", 2)[0]
+}
+
+// Action is a `{{ }}` block found in a string
+type Action struct {
+	// Byte offsets of the content between the delimiters and the trim markers
+	Start  int
+	End    int
+	Value  string
+	Tokens []TemplateToken
+}
+
+// FindActions returns the actions of a template string
+// Delimiters inside string literals and comments do not close the action
+func FindActions(s string) []Action {
+	actions := make([]Action, 0)
+	i := 0
+	for {
+		open := strings.Index(s[i:], "{{")
+		if open < 0 {
+			return actions
+		}
+		start := i + open + 2
+		// Left trim marker
+		if strings.HasPrefix(s[start:], "- ") || strings.HasPrefix(s[start:], "-\t") || strings.HasPrefix(s[start:], "-\n") {
+			start++
+		}
+		end := findActionEnd(s, start)
+		if end < 0 {
+			return actions
+		}
+		close := end
+		// Right trim marker
+		if end-2 >= start && (s[end-2] == ' ' || s[end-2] == '\t' || s[end-2] == '\n') && s[end-1] == '-' {
+			end--
+		}
+		actions = append(actions, Action{
+			Start:  start,
+			End:    end,
+			Value:  s[start:end],
+			Tokens: LexTemplate(s, start, end),
+		})
+		i = close + 2
+	}
+}
+
+// findActionEnd returns the offset of the `}}` closing an action, -1 if the action is not closed
+func findActionEnd(s string, i int) int {
+	for i < len(s) {
+		switch {
+		case strings.HasPrefix(s[i:], "}}"):
+			return i
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return -1
+			}
+			i += end + 4
+		case s[i] == '"' || s[i] == '\'' || s[i] == '`':
+			i = skipQuoted(s, i)
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+// skipQuoted returns the offset following a quoted literal starting at i
+func skipQuoted(s string, i int) int {
+	quote := s[i]
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == '\\' && quote != '`' {
+			j++
+			continue
+		}
+		if s[j] == quote {
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scanIdent returns the offset following the identifier starting at i
+func scanIdent(s string, i int, end int) int {
+	for i < end {
+		r, size := utf8.DecodeRuneInString(s[i:end])
+		if !isIdentRune(r) {
+			return i
+		}
+		i += size
+	}
+	return i
+}
+
+// LexTemplate splits the content of an action into tokens
+// start and end are the byte offsets of the content in s
+func LexTemplate(s string, start int, end int) []TemplateToken {
+	tokens := make([]TemplateToken, 0)
+	add := func(kind TemplateTokenKind, from int, to int) {
+		tokens = append(tokens, TemplateToken{Kind: kind, Value: s[from:to], Start: from, End: to})
+	}
+	i := start
+	for i < end {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(s[i:end], "/*"):
+			j := strings.Index(s[i:end], "*/")
+			if j < 0 {
+				add(TokenComment, i, end)
+				return tokens
+			}
+			add(TokenComment, i, i+j+2)
+			i += j + 2
+		case c == '"' || c == '`':
+			j := skipQuoted(s[:end], i)
+			add(TokenString, i, j)
+			i = j
+		case c == '\'':
+			j := skipQuoted(s[:end], i)
+			add(TokenNumber, i, j)
+			i = j
+		case c == '.':
+			// A field chain, or the dot alone
+			j := i + 1
+			for {
+				k := scanIdent(s, j, end)
+				if k == j || k >= end || s[k] != '.' {
+					j = k
+					break
+				}
+				j = k + 1
+			}
+			add(TokenField, i, j)
+			i = j
+		case c == '$':
+			j := scanIdent(s, i+1, end)
+			add(TokenVariable, i, j)
+			i = j
+		case c == '|':
+			add(TokenPipe, i, i+1)
+			i++
+		case strings.HasPrefix(s[i:end], ":="):
+			add(TokenOperator, i, i+2)
+			i += 2
+		case c == '=' || c == '(' || c == ')' || c == ',':
+			add(TokenOperator, i, i+1)
+			i++
+		case unicode.IsDigit(rune(c)) || ((c == '-' || c == '+') && i+1 < end && unicode.IsDigit(rune(s[i+1]))):
+			j := i + 1
+			for j < end && (isIdentRune(rune(s[j])) || s[j] == '.' || ((s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E' || s[j-1] == 'p' || s[j-1] == 'P'))) {
+				j++
+			}
+			add(TokenNumber, i, j)
+			i = j
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:end])
+			if !isIdentRune(r) {
+				i += size
+				continue
+			}
+			j := scanIdent(s, i, end)
+			kind := TokenFunction
+			if TemplateKeywords[s[i:j]] {
+				kind = TokenKeyword
+			}
+			add(kind, i, j)
+			i = j
+		}
+	}
+	return tokens
+}
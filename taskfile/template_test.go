@@ -0,0 +1,143 @@
+package taskfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindActions(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"echo {{.A}} {{ .B }}", []string{".A", " .B "}},
+		{"{{- .A -}}", []string{" .A "}},
+		// A minus sign without a space is a number, not a trim marker
+		{"{{-1}}", []string{"-1"}},
+		{`{{"}}" | print}}`, []string{`"}}" | print`}},
+		{"{{/* }} */}}", []string{"/* }} */"}},
+		// Actions which are not closed are left out
+		{"{{.A}} {{.B", []string{".A"}},
+		{`{{"a}}`, []string{}},
+		{"{{/* a }}", []string{}},
+		{"}} {{", []string{}},
+	}
+	for _, tt := range tests {
+		got := make([]string, 0)
+		for _, a := range FindActions(tt.s) {
+			got = append(got, a.Value)
+			if tt.s[a.Start:a.End] != a.Value {
+				t.Errorf("FindActions(%s): %q is not at %d:%d", tt.s, a.Value, a.Start, a.End)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindActions(%s) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestLexTemplate(t *testing.T) {
+	type token struct {
+		kind  TemplateTokenKind
+		value string
+	}
+	tests := []struct {
+		s    string
+		want []token
+	}{
+		{`$x := .A.B | printf "%s" 1.5e-3`, []token{
+			{TokenVariable, "$x"}, {TokenOperator, ":="}, {TokenField, ".A.B"}, {TokenPipe, "|"},
+			{TokenFunction, "printf"}, {TokenString, `"%s"`}, {TokenNumber, "1.5e-3"},
+		}},
+		{"if not (eq . 'a') ", []token{
+			{TokenKeyword, "if"}, {TokenFunction, "not"}, {TokenOperator, "("}, {TokenFunction, "eq"},
+			{TokenField, "."}, {TokenNumber, "'a'"}, {TokenOperator, ")"},
+		}},
+		// Malformed templates give the tokens up to the error
+		{`print "a`, []token{{TokenFunction, "print"}, {TokenString, `"a`}}},
+		{"`a", []token{{TokenString, "`a"}}},
+		{"/* a", []token{{TokenComment, "/* a"}}},
+		{".A. | $", []token{{TokenField, ".A."}, {TokenPipe, "|"}, {TokenVariable, "$"}}},
+		{"@ .A # -x", []token{{TokenField, ".A"}, {TokenFunction, "x"}}},
+		{"é.A", []token{{TokenFunction, "é"}, {TokenField, ".A"}}},
+	}
+	for _, tt := range tests {
+		got := make([]token, 0)
+		for _, tk := range LexTemplate(tt.s, 0, len(tt.s)) {
+			got = append(got, token{tk.Kind, tk.Value})
+			if tt.s[tk.Start:tk.End] != tk.Value {
+				t.Errorf("LexTemplate(%s): %q is not at %d:%d", tt.s, tk.Value, tk.Start, tk.End)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LexTemplate(%s) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	type node struct {
+		kind     TemplateNodeKind
+		value    string
+		function string
+		arg      int
+	}
+	tests := []struct {
+		s    string
+		want []node
+	}{
+		{"{{.A.B | default (env `B`)}}", []node{
+			{NodeField, ".A.B", "", -1}, {NodeFunction, "default", "", -1},
+			{NodeFunction, "env", "", -1}, {NodeString, "`B`", "env", 0},
+		}},
+		{"{{if eq $.A 1}}{{$x := true}}{{end}}", []node{
+			{NodeFunction, "eq", "", -1}, {NodeVariable, "$.A", "eq", 0}, {NodeNumber, "1", "eq", 1},
+			{NodeVariable, "$x", "", -1}, {NodeBool, "true", "", -1},
+		}},
+		{"{{(index . 0).Name}}", []node{
+			{NodeFunction, "index", "", -1}, {NodeDot, ".", "index", 0}, {NodeNumber, "0", "index", 1},
+		}},
+	}
+	for _, tt := range tests {
+		nodes, err := ParseTemplate(tt.s)
+		if err != nil {
+			t.Errorf("ParseTemplate(%s) failed: %s", tt.s, err)
+			continue
+		}
+		got := make([]node, 0)
+		for _, n := range nodes {
+			got = append(got, node{n.Kind, n.Value, n.Function, n.Arg})
+			if tt.s[n.Start:n.End] != n.Value {
+				t.Errorf("ParseTemplate(%s): %q is not at %d:%d", tt.s, n.Value, n.Start, n.End)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTemplate(%s) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		s    string
+		line int
+	}{
+		{"{{.A", 0},
+		{"{{| print}}", 0},
+		{"a\n{{if .A}}", 1},
+		{"{{end}}", 0},
+		{`{{"a}}`, 0},
+		{"{{$x}}", 0},
+		{"a\nb\n{{(.A}}", 2},
+	}
+	for _, tt := range tests {
+		nodes, err := ParseTemplate(tt.s)
+		if err == nil {
+			t.Errorf("ParseTemplate(%s) = %v, want an error", tt.s, nodes)
+			continue
+		}
+		if err.Line != tt.line || err.Message == "" {
+			t.Errorf("ParseTemplate(%s) failed at line %d: %q, want line %d", tt.s, err.Line, err.Message, tt.line)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package taskfile
+
+import (
+	"strings"
+	"text/template/parse"
+)
+
+type TemplateNodeKind int
+
+const (
+	// A field of the data, `.VAR` or `.A.B`
+	NodeField TemplateNodeKind = iota
+	// A template variable, `$name` or `$name.A`
+	NodeVariable
+	// A function called by a command, `printf`
+	NodeFunction
+	// The dot alone
+	NodeDot
+	NodeString
+	NodeNumber
+	NodeBool
+	NodeNil
+)
+
+// BuiltinFuncs are the functions of text/template, available besides the functions of Task
+var BuiltinFuncs = map[string]bool{
+	"and": true, "or": true, "not": true, "len": true, "index": true, "slice": true,
+	"print": true, "printf": true, "println": true, "html": true, "js": true, "urlquery": true,
+	"call": true, "eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+}
+
+// TemplateNode is an operand of a command of a template
+type TemplateNode struct {
+	Kind  TemplateNodeKind `json:"kind"`
+	Value string           `json:"value"`
+	// Byte offsets of the node in the string holding the template
+	Start int `json:"-"`
+	End   int `json:"-"`
+	// Function is the function called by the command the node is an argument of
+	// It is empty for the operand called by the command and for the commands calling a field or a variable
+	Function string `json:"function,omitempty"`
+	// Arg is the index of the node in the arguments of the command, -1 for the operand called
+	Arg int `json:"arg"`
+	// Range of the node in the document, filled when analyzing a Taskfile
+	Range Range `json:"range"`
+}
+
+// ParseTemplate returns the operands of the commands of a template, in the order of the string
+// Functions are not checked, an error is returned if the template is not valid
+func ParseTemplate(s string) ([]TemplateNode, *TemplateError) {
+	tree := parse.New("")
+	tree.Mode = parse.ParseComments | parse.SkipFuncCheck
+	_, err := tree.Parse(s, "{{", "}}", make(map[string]*parse.Tree))
+	if err != nil {
+		return nil, newTemplateError(err)
+	}
+	w := &templateWalker{text: s, nodes: make([]TemplateNode, 0)}
+	w.walk(tree.Root)
+	return w.nodes, nil
+}
+
+type templateWalker struct {
+	text  string
+	nodes []TemplateNode
+}
+
+func (w *templateWalker) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			w.walk(child)
+		}
+	case *parse.ActionNode:
+		w.pipe(n.Pipe)
+	case *parse.IfNode:
+		w.branch(&n.BranchNode)
+	case *parse.RangeNode:
+		w.branch(&n.BranchNode)
+	case *parse.WithNode:
+		w.branch(&n.BranchNode)
+	case *parse.TemplateNode:
+		w.pipe(n.Pipe)
+	}
+}
+
+func (w *templateWalker) branch(n *parse.BranchNode) {
+	w.pipe(n.Pipe)
+	w.walk(n.List)
+	w.walk(n.ElseList)
+}
+
+func (w *templateWalker) pipe(n *parse.PipeNode) {
+	if n == nil {
+		return
+	}
+	for _, v := range n.Decl {
+		w.add(v, "", -1)
+	}
+	for _, cmd := range n.Cmds {
+		function := ""
+		if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+			function = ident.Ident
+		}
+		for i, arg := range cmd.Args {
+			if i == 0 {
+				w.add(arg, "", -1)
+			} else {
+				w.add(arg, function, i-1)
+			}
+		}
+	}
+}
+
+// add collects an operand, the pipelines in parenthesis are walked
+func (w *templateWalker) add(node parse.Node, function string, arg int) {
+	var kind TemplateNodeKind
+	var value string
+	switch n := node.(type) {
+	case *parse.PipeNode:
+		w.pipe(n)
+		return
+	case *parse.ChainNode:
+		// The fields of a chain apply to the result of the operand
+		w.add(n.Node, function, arg)
+		return
+	case *parse.FieldNode:
+		kind, value = NodeField, n.String()
+	case *parse.VariableNode:
+		kind, value = NodeVariable, n.String()
+	case *parse.IdentifierNode:
+		kind, value = NodeFunction, n.Ident
+	case *parse.DotNode:
+		kind, value = NodeDot, "."
+	case *parse.StringNode:
+		kind, value = NodeString, n.Quoted
+	case *parse.NumberNode:
+		kind, value = NodeNumber, n.Text
+	case *parse.BoolNode:
+		kind, value = NodeBool, n.String()
+	case *parse.NilNode:
+		kind, value = NodeNil, "nil"
+	default:
+		return
+	}
+	start := int(node.Position())
+	// The position of a chain of fields is the position of its second field
+	if i := strings.Index(value[1:], ".") + 1; i > 0 && (start+len(value) > len(w.text) || w.text[start:start+len(value)] != value) {
+		start -= i
+	}
+	if start < 0 || start+len(value) > len(w.text) {
+		return
+	}
+	w.nodes = append(w.nodes, TemplateNode{Kind: kind, Value: value, Start: start, End: start + len(value), Function: function, Arg: arg})
+}
+
+// FieldName returns the name of the variable of a field, `.A.B` gives `A`
+func (n TemplateNode) FieldName() string {
+	return TemplateToken{Kind: TokenField, Value: n.Value}.FieldName()
+}
+
+// IsFunction returns true if a name is a function of Task or of text/template
+func IsFunction(name string) bool {
+	return TaskFuncs[name] || BuiltinFuncs[name]
+}
+
+// NodeAt returns the operand of an expression at a position, nil if the position is not on an operand
+func (e Expr) NodeAt(line int, col int) *TemplateNode {
+	for i, n := range e.Nodes {
+		if IsInRange(line, col, n.Range) {
+			return &e.Nodes[i]
+		}
+	}
+	return nil
+}
@@ -7,46 +7,82 @@ import (
 )
 
 type Var struct {
-	Name  string `json:"name"`
-	Range Range  `json:"range"`
+	Name      string `json:"name"`
+	Range     Range  `json:"range"`
+	NameRange Range  `json:"nameRange"`
+	// Value holds the literal value of the variable as written in the Taskfile
+	Value string `json:"value"`
+	// Sh holds the command of a dynamic variable declared with `sh:`
+	Sh string `json:"sh,omitempty"`
+	// File holds the path of the dotenv file declaring the variable, empty for the variables of a Taskfile
+	File string `json:"file,omitempty"`
 }
 
-func GetVars(node *ast.MappingValueNode) (map[string]*Var, error) {
+func GetVars(node *ast.MappingValueNode, src *Source) (map[string]*Var, error) {
+	return getVarsOf("vars", node, src)
+}
+
+// GetEnv returns the environment variables declared in an `env` entry
+func GetEnv(node *ast.MappingValueNode, src *Source) (map[string]*Var, error) {
+	return getVarsOf("env", node, src)
+}
+
+func getVarsOf(property string, node *ast.MappingValueNode, src *Source) (map[string]*Var, error) {
 	sn, ok := node.Key.(*ast.StringNode)
 	if !ok {
 		return nil, fmt.Errorf("OOPS")
 	}
-	if sn.Value == "vars" {
+	if sn.Value == property {
 		switch varsNode := node.Value.(type) {
 		case *ast.MappingValueNode:
-			key, val := ExtractVarFromMappingValueNode(varsNode)
+			key, val := ExtractVarFromMappingValueNode(varsNode, src)
 			vars := make(map[string]*Var)
 			vars[key] = val
 			return vars, nil
 		case *ast.MappingNode:
-			return ExtractVarsFromMappingNode(varsNode)
+			return ExtractVarsFromMappingNode(varsNode, src)
 		}
 	}
 	return nil, nil
 }
 
-func ExtractVarsFromMappingNode(node *ast.MappingNode) (map[string]*Var, error) {
+func ExtractVarsFromMappingNode(node *ast.MappingNode, src *Source) (map[string]*Var, error) {
 	vars := make(map[string]*Var)
 	for _, v := range node.Values {
-		key, val := ExtractVarFromMappingValueNode(v)
+		key, val := ExtractVarFromMappingValueNode(v, src)
 		vars[key] = val
 	}
 	return vars, nil
 }
 
-func ExtractVarFromMappingValueNode(node *ast.MappingValueNode) (string, *Var) {
+func ExtractVarFromMappingValueNode(node *ast.MappingValueNode, src *Source) (string, *Var) {
 	name := node.Key.(*ast.StringNode).Value
-	res := Analyze(node)
-	var r Range = []int{
-		node.Key.(*ast.StringNode).Token.Position.Line - 1,
-		node.Key.(*ast.StringNode).Token.Position.Column - 1,
-		res.LastToken.Position.Line - 1,
-		res.LastToken.Position.Column + len(name) - 1,
+	res := Analyze(node, src)
+	v := &Var{Name: name, Range: EntryRange(node, res, src), NameRange: src.TokenRange(node.Key.GetToken())}
+	switch value := node.Value.(type) {
+	case *ast.MappingValueNode:
+		// Dynamic variables are declared as `sh: <command>`
+		if key, ok := value.Key.(*ast.StringNode); ok && key.Value == "sh" {
+			v.Sh = ScalarValue(value.Value)
+		}
+	default:
+		v.Value = ScalarValue(value)
+	}
+	return name, v
+}
+
+// ScalarValue returns the string representation of a scalar node
+// Any other node will return an empty string
+func ScalarValue(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		return n.Value
+	case *ast.LiteralNode:
+		return n.Value.Value
+	case *ast.NullNode:
+		return ""
+	case ast.ScalarNode:
+		return n.GetToken().Value
 	}
-	return node.Key.(*ast.StringNode).Value, &Var{Name: name, Range: r}
+	return ""
 }